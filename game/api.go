@@ -0,0 +1,200 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// limites da API: consultas grandes demais e corpos enormes sao cortados,
+// e poucas re-simulacoes rodam ao mesmo tempo
+const (
+	API_MAX_LIMIT  = 100
+	API_MAX_BODY   = 1 << 20 // um replay de MAX_REPLAY_TICKS com entrada em todo tick cabe
+	API_MAX_VERIFY = 4
+)
+
+// NewAPIHandler expoe o ranking do store em JSON:
+//
+//	GET  /api/top?n=10&offset=0&best=1 melhores scores (cheats=1 inclui cheats)
+//	GET  /api/ranking/{periodo}?n=10   day, week, month ou all
+//	GET  /api/players/{nome}/scores    historico do jogador, mais recentes primeiro
+//	POST /api/scores                   envia um score (com replay)
+//
+// Envios passam pelo store, entao com OpenScoreStore sao re-simulados
+// pelo VerifyingStore antes de gravar
+func NewAPIHandler(store ScoreStore) http.Handler {
+	api := &apiHandler{store: store, verify: make(chan struct{}, API_MAX_VERIFY)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/top", api.top)
+	mux.HandleFunc("GET /api/ranking/{period}", api.ranking)
+	mux.HandleFunc("GET /api/players/{name}/scores", api.history)
+	mux.HandleFunc("POST /api/scores", api.submit)
+	return withCORS(mux)
+}
+
+type apiHandler struct {
+	store  ScoreStore
+	verify chan struct{} // vagas para re-simular envios
+}
+
+// qualquer pagina pode ler o ranking
+func withCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (api *apiHandler) top(w http.ResponseWriter, r *http.Request) {
+	q, err := apiQuery(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	api.writeScores(w, q)
+}
+
+func (api *apiHandler) ranking(w http.ResponseWriter, r *http.Request) {
+	q, err := apiQuery(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q.Since, err = periodStart(r.PathValue("period"), time.Now()); err != nil {
+		writeAPIError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	api.writeScores(w, q)
+}
+
+func (api *apiHandler) history(w http.ResponseWriter, r *http.Request) {
+	q, err := apiQuery(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q.Player = r.PathValue("name")
+	q.Recent = true
+	q.IncludeCheated = true // o historico e do jogador, com tudo que ele jogou
+	api.writeScores(w, q)
+}
+
+// n (1 a API_MAX_LIMIT, padrao 10), offset para paginar, best=1 para so o
+// melhor score de cada jogador e cheats=1 para incluir partidas com cheats
+func apiQuery(r *http.Request) (ScoreQuery, error) {
+	q := ScoreQuery{Limit: 10}
+	if n := r.URL.Query().Get("n"); n != "" {
+		limit, err := strconv.Atoi(n)
+		if err != nil || limit < 1 || limit > API_MAX_LIMIT {
+			return q, errors.New("n deve ser um numero de 1 a " + strconv.Itoa(API_MAX_LIMIT))
+		}
+		q.Limit = limit
+	}
+	if o := r.URL.Query().Get("offset"); o != "" {
+		offset, err := strconv.Atoi(o)
+		if err != nil || offset < 0 {
+			return q, errors.New("offset deve ser um numero maior ou igual a 0")
+		}
+		q.Offset = offset
+	}
+	q.BestPerPlayer = r.URL.Query().Get("best") == "1"
+	q.IncludeCheated = r.URL.Query().Get("cheats") == "1"
+	return q, nil
+}
+
+func (api *apiHandler) writeScores(w http.ResponseWriter, q ScoreQuery) {
+	scores, err := api.store.Top(q)
+	if err != nil {
+		log.Printf("API: erro ao buscar ranking: %v", err)
+		writeAPIError(w, http.StatusServiceUnavailable, "ranking indisponivel")
+		return
+	}
+	// o replay so interessa na verificacao, e o id do perfil fica no
+	// servidor: com ele qualquer um assinaria scores como outro jogador
+	for i := range scores {
+		scores[i].Replay = nil
+		scores[i].JogadorID = ""
+	}
+	if scores == nil {
+		scores = []Score{}
+	}
+	writeJSON(w, http.StatusOK, scores)
+}
+
+// recebe um score com replay (obrigatorio). A data e a do recebimento e a
+// verificacao e do servidor: o que o cliente manda nesses campos e
+// ignorado, assim como id, perfil, partida, posicao e maquina, que
+// ninguem de fora pode provar
+func (api *apiHandler) submit(w http.ResponseWriter, r *http.Request) {
+	var s Score
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, API_MAX_BODY))
+	if err := dec.Decode(&s); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "JSON invalido: "+err.Error())
+		return
+	}
+
+	s.Nome = strings.TrimSpace(s.Nome)
+	switch {
+	case s.Nome == "":
+		writeAPIError(w, http.StatusUnprocessableEntity, "nome obrigatorio")
+		return
+	case len([]rune(s.Nome)) > 40:
+		writeAPIError(w, http.StatusUnprocessableEntity, "nome com mais de 40 caracteres")
+		return
+	case s.Nivel < 0 || s.MaxCombo < 0:
+		// pontos negativos existem (fruta de penalidade); se batem com o
+		// replay quem decide e o VerifyScore
+		writeAPIError(w, http.StatusUnprocessableEntity, "nivel e combo nao podem ser negativos")
+		return
+	case s.Replay == nil:
+		// sem replay nao ha o que re-simular
+		writeAPIError(w, http.StatusUnprocessableEntity, "replay obrigatorio")
+		return
+	}
+	// id do cliente podia repetir um _id ja gravado, e a chave duplicada
+	// passa como "ja enviado" sem gravar nada
+	s.ID = newScoreID()
+	s.Data = time.Now()
+	s.JogadorID, s.Partida, s.Slot, s.Host = "", "", 0, ""
+
+	// sem vaga o cliente tenta de novo depois, em vez de enfileirar CPU
+	select {
+	case api.verify <- struct{}{}:
+		defer func() { <-api.verify }()
+	default:
+		w.Header().Set("Retry-After", "5")
+		writeAPIError(w, http.StatusServiceUnavailable, "servidor ocupado verificando scores")
+		return
+	}
+	if err := api.store.Save(s); err != nil {
+		if errors.Is(err, ErrScoreRejected) {
+			writeAPIError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		log.Printf("API: erro ao salvar score de %s: %v", s.Nome, err)
+		writeAPIError(w, http.StatusServiceUnavailable, "nao foi possivel salvar o score")
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"id": s.ID})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"erro": msg})
+}
@@ -0,0 +1,133 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// score de verdade de uma partida do autopilot, pronto para a API
+func apiTestScore(t *testing.T, seed int64) Score {
+	t.Helper()
+	sim := PlayAutopilot(60, 25, seed, 500)
+	replay := sim.Replay()
+	replay.Jogador = "ana"
+	s := newScore(sim.Arena, 0, "ana", simEpoch)
+	s.Replay = replay
+	return s
+}
+
+func postScore(t *testing.T, h http.Handler, s Score) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(s)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/scores", bytes.NewReader(body)))
+	return rec
+}
+
+// perfil, partida, posicao e maquina vem do cliente e nao sao gravados, e
+// o id do perfil nao sai nas leituras
+func TestAPISubmitIgnoresClientIdentity(t *testing.T) {
+	store := NewMemoryStore()
+	h := NewAPIHandler(NewVerifyingStore(store))
+
+	s := apiTestScore(t, 3)
+	s.JogadorID = "perfil-de-outro"
+	s.Partida = "partida"
+	s.Host = "outra-maquina"
+	if rec := postScore(t, h, s); rec.Code != http.StatusCreated {
+		t.Fatalf("POST: %d %s", rec.Code, rec.Body)
+	}
+
+	saved, _ := store.Top(ScoreQuery{IncludeCheated: true})
+	if len(saved) != 1 {
+		t.Fatalf("%d scores gravados, esperado 1", len(saved))
+	}
+	if got := saved[0]; got.JogadorID != "" || got.Partida != "" || got.Host != "" {
+		t.Errorf("campos do cliente gravados: perfil %q, partida %q, host %q", got.JogadorID, got.Partida, got.Host)
+	}
+
+	store.Save(Score{ID: "x", Nome: "bia", Pontos: 1, JogadorID: "uuid-da-bia", Verificado: true})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/top", nil))
+	if bytes.Contains(rec.Body.Bytes(), []byte("jogador_id")) {
+		t.Errorf("/api/top devolveu jogador_id: %s", rec.Body)
+	}
+}
+
+// id mandado pelo cliente nao vale: repetir um id gravado nao pode
+// responder 201 sem gravar nada
+func TestAPISubmitAssignsID(t *testing.T) {
+	store := NewMemoryStore()
+	h := NewAPIHandler(NewVerifyingStore(store))
+	store.Save(Score{ID: "repetido", Nome: "bia", Pontos: 1})
+
+	s := apiTestScore(t, 3)
+	s.ID = "repetido"
+	rec := postScore(t, h, s)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST: %d %s", rec.Code, rec.Body)
+	}
+	var resp map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp["id"] == "" || resp["id"] == "repetido" {
+		t.Errorf("id da resposta: %q", resp["id"])
+	}
+	if saved, _ := store.Top(ScoreQuery{IncludeCheated: true}); len(saved) != 2 {
+		t.Errorf("%d scores gravados, esperado 2", len(saved))
+	}
+}
+
+// joga indo atras da fruta de penalidade ate os pontos ficarem negativos
+func penaltyRun(t *testing.T, seed int64) *Simulation {
+	t.Helper()
+	sim := NewSimulation(60, 25, seed)
+	for sim.Arena.Points >= 0 {
+		a := sim.Arena
+		head, dir := a.Snake.Body[0], a.Snake.Dir
+		var in []Input
+		for _, f := range a.Foods {
+			if f.FoodType != FOOD_PENALTY {
+				continue
+			}
+			switch {
+			case f.X > head.X && dir.X != -1:
+				in = []Input{INPUT_RIGHT}
+			case f.X < head.X && dir.X != 1:
+				in = []Input{INPUT_LEFT}
+			case f.Y > head.Y && dir.Y != -1:
+				in = []Input{INPUT_DOWN}
+			case f.Y < head.Y && dir.Y != 1:
+				in = []Input{INPUT_UP}
+			}
+			break
+		}
+		if !sim.Step(in...) {
+			t.Fatalf("seed %d: morreu antes da fruta de penalidade", seed)
+		}
+	}
+	return sim
+}
+
+// partida que termina com pontos negativos e valida e entra
+func TestAPISubmitNegativePoints(t *testing.T) {
+	sim := penaltyRun(t, 47)
+	replay := sim.Replay()
+	replay.Jogador = "ana"
+	s := newScore(sim.Arena, 0, "ana", simEpoch)
+	s.Replay = replay
+	if s.Pontos >= 0 {
+		t.Fatalf("pontos: %d, esperado negativo", s.Pontos)
+	}
+
+	store := NewMemoryStore()
+	if rec := postScore(t, NewAPIHandler(NewVerifyingStore(store)), s); rec.Code != http.StatusCreated {
+		t.Fatalf("POST: %d %s", rec.Code, rec.Body)
+	}
+	saved, _ := store.Top(ScoreQuery{})
+	if len(saved) != 1 || saved[0].Pontos != s.Pontos {
+		t.Errorf("gravados: %v", saved)
+	}
+}
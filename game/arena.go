@@ -7,62 +7,72 @@ import (
 )
 
 type Arena struct {
-	X, Y            int
-	Width, Height   int
-	Snake           *Snake
-	Foods           []*Food
-	PowerUps        []*PowerUp
-	Obstacles       []*Obstacle
-	Bosses          []*Boss
-	Points          int
-	Level           int
-	ComboSystem     *ComboSystem
-	Messages        []GameMessage
-	lastFoodTime    time.Time
-	foodCooldown    time.Duration
-	maxFoods        int
-	speedMultiplier float64
-	lastBossSpawn   time.Time
-	bossCooldown    time.Duration
+	X, Y             int
+	Width, Height    int
+	*Player          // jogador da vez, o unico fora do modo dois jogadores
+	Players          []*Player
+	Mode             string
+	Foods            []*Food
+	PowerUps         []*PowerUp
+	Obstacles        []*Obstacle
+	Bosses           []*Boss
+	Level            int
+	Messages         []GameMessage
+	lastFoodTime     time.Time
+	foodCooldown     time.Duration
+	maxFoods         int
+	speedMultiplier  float64
+	lastBossSpawn    time.Time
+	bossCooldown     time.Duration
+	CheatsUsed       []string
+	lastPowerUpSpawn time.Time
+	pathGrid         []bool
+	pathBudget       int
+	clock            Clock
+	rng              *rand.Rand
 }
 
-func newArena(width, height int) *Arena {
-	rand.Seed(time.Now().UnixNano())
+func newArena(width, height int, mode string, players int, clock Clock, rng *rand.Rand) *Arena {
 	a := &Arena{
-		X:         2,
-		Y:         3,
-		Width:     width,
-		Height:    height,
-		Snake:     newSnake(),
-		Foods:     make([]*Food, 0),
-		PowerUps:  make([]*PowerUp, 0),
-		Obstacles: make([]*Obstacle, 0),
-		Bosses:    make([]*Boss, 0),
-		Messages:  make([]GameMessage, 0),
-		ComboSystem: &ComboSystem{
-			ComboTimeout: 3 * time.Second,
-			MaxCombo:     0,
-		},
+		X:               2,
+		Y:               3,
+		Width:           width,
+		Height:          height,
+		Players:         newPlayers(players),
+		Mode:            mode,
+		Foods:           make([]*Food, 0),
+		PowerUps:        make([]*PowerUp, 0),
+		Obstacles:       make([]*Obstacle, 0),
+		Bosses:          make([]*Boss, 0),
+		Messages:        make([]GameMessage, 0),
 		foodCooldown:    2 * time.Second,
 		maxFoods:        3,
 		speedMultiplier: 1.0,
 		Level:           1,
 		bossCooldown:    30 * time.Second, // Boss a cada 30 segundos
+		clock:           clock,
+		rng:             rng,
 	}
+	a.Player = a.Players[0]
 	a.placeFood()
 	return a
 }
 
+// horario atual da simulacao
+func (a *Arena) Now() time.Time {
+	return a.clock.Now()
+}
+
 func (a *Arena) AddMessage(text string, duration time.Duration) {
 	a.Messages = append(a.Messages, GameMessage{
 		Text:      text,
-		CreatedAt: time.Now(),
+		CreatedAt: a.Now(),
 		Duration:  duration,
 	})
 }
 
 func (a *Arena) RemoveExpiredMessages() {
-	now := time.Now()
+	now := a.Now()
 	validMessages := make([]GameMessage, 0)
 	for _, msg := range a.Messages {
 		if now.Sub(msg.CreatedAt) < msg.Duration {
@@ -84,17 +94,17 @@ func (a *Arena) increaseDifficulty() {
 }
 
 func (a *Arena) placeFood() {
-	if time.Since(a.lastFoodTime) < a.foodCooldown || len(a.Foods) >= a.maxFoods {
+	if a.Now().Sub(a.lastFoodTime) < a.foodCooldown || len(a.Foods) >= a.maxFoods {
 		return
 	}
 
 	for attempts := 0; attempts < 50; attempts++ {
-		x := rand.Intn(a.Width-4) + a.X + 2
-		y := rand.Intn(a.Height-4) + a.Y + 2
+		x := a.rng.Intn(a.Width-4) + a.X + 2
+		y := a.rng.Intn(a.Height-4) + a.Y + 2
 		c := Coord{X: x, Y: y}
 
 		if a.isPositionValid(c) {
-			randType := rand.Float32()
+			randType := a.rng.Float32()
 			var foodType int
 			var points int
 			var lifetime time.Duration
@@ -117,31 +127,35 @@ func (a *Arena) placeFood() {
 				Coord:     c,
 				Points:    points,
 				FoodType:  foodType,
-				SpawnTime: time.Now(),
+				SpawnTime: a.Now(),
 				Lifetime:  lifetime,
 			}
 
 			a.Foods = append(a.Foods, newFood)
-			a.lastFoodTime = time.Now()
-			a.foodCooldown = time.Duration(2+rand.Intn(3)) * time.Second
+			a.lastFoodTime = a.Now()
+			a.foodCooldown = time.Duration(2+a.rng.Intn(3)) * time.Second
 			return
 		}
 	}
 }
 
 func (a *Arena) placeObstacle() {
+	if a.rng.Float64() < a.movingObstacleChance() && a.placeMovingObstacle() {
+		return
+	}
+
 	for attempts := 0; attempts < 30; attempts++ {
-		x := rand.Intn(a.Width-4) + a.X + 2
-		y := rand.Intn(a.Height-4) + a.Y + 2
+		x := a.rng.Intn(a.Width-4) + a.X + 2
+		y := a.rng.Intn(a.Height-4) + a.Y + 2
 		c := Coord{X: x, Y: y}
 
 		if a.isPositionValid(c) {
 			obstacle := &Obstacle{
 				Coord:        c,
 				ObstacleType: OBSTACLE_WALL,
-				IsTemporary:  rand.Float32() < 0.3,
-				SpawnTime:    time.Now(),
-				Lifetime:     time.Duration(10+rand.Intn(20)) * time.Second,
+				IsTemporary:  a.rng.Float32() < 0.3,
+				SpawnTime:    a.Now(),
+				Lifetime:     time.Duration(10+a.rng.Intn(20)) * time.Second,
 			}
 			a.Obstacles = append(a.Obstacles, obstacle)
 			return
@@ -150,7 +164,7 @@ func (a *Arena) placeObstacle() {
 }
 
 func (a *Arena) trySpawnBoss() {
-	currentTime := time.Now()
+	currentTime := a.Now()
 
 	// quantos bosses devem existir no nivel atual?
 	expectedBossCount := a.Level / 10 // a cada 10 → +1 estrangeiro
@@ -160,7 +174,7 @@ func (a *Arena) trySpawnBoss() {
 
 	aliveCount := 0
 	for _, b := range a.Bosses {
-		if b.IsAlive {
+		if b.IsAlive && !b.IsMinion {
 			aliveCount++
 		}
 	}
@@ -173,15 +187,15 @@ func (a *Arena) trySpawnBoss() {
 			chance = 1.0
 		}
 
-		if rand.Float64() < chance || a.Level >= 6 {
-			if time.Since(a.lastBossSpawn) > 8*time.Second { // evita spawn em sequencia
-				boss := newBoss(a.Width, a.Height, a.Snake)
+		if a.rng.Float64() < chance || a.Level >= 6 {
+			if currentTime.Sub(a.lastBossSpawn) > 8*time.Second { // evita spawn em sequencia
+				boss := newBoss(a)
 				a.Bosses = append(a.Bosses, boss)
 				a.lastBossSpawn = currentTime
 
 				count := len(a.Bosses)
 				if count == 1 {
-					a.AddMessage("Um estrangeiro invadiu seu mundo!", 4*time.Second)
+					a.AddMessage(fmt.Sprintf("Um estrangeiro %s invadiu seu mundo!", boss.Species().Name), 4*time.Second)
 				} else {
 					a.AddMessage(fmt.Sprintf("Outro estrangeiro chegou! Agora sao %d!", count), 4*time.Second)
 				}
@@ -191,8 +205,8 @@ func (a *Arena) trySpawnBoss() {
 }
 
 func (a *Arena) isPositionValid(c Coord) bool {
-	// check cobra
-	if a.Snake.IsOnPosition(c) {
+	// check cobras
+	if a.isSnake(c) {
 		return false
 	}
 	// check comidas
@@ -223,7 +237,7 @@ func (a *Arena) isPositionValid(c Coord) bool {
 }
 
 func (a *Arena) removeExpiredItems() {
-	now := time.Now()
+	now := a.Now()
 
 	// remove comidas expiradas
 	validFoods := make([]*Food, 0)
@@ -242,10 +256,33 @@ func (a *Arena) removeExpiredItems() {
 		}
 	}
 	a.Obstacles = validObstacles
+
+	// remove power-ups expirados
+	validPowerUps := make([]*PowerUp, 0)
+	for _, p := range a.PowerUps {
+		if now.Sub(p.SpawnTime) < p.Lifetime {
+			validPowerUps = append(validPowerUps, p)
+		}
+	}
+	a.PowerUps = validPowerUps
+}
+
+func (a *Arena) isWall(c Coord) bool {
+	return c.X <= a.X || c.X >= a.X+a.Width-1 ||
+		c.Y <= a.Y || c.Y >= a.Y+a.Height-1
+}
+
+func (a *Arena) isObstacle(c Coord) bool {
+	for _, obs := range a.Obstacles {
+		if obs.X == c.X && obs.Y == c.Y {
+			return true
+		}
+	}
+	return false
 }
 
 func (a *Arena) updateCombo() {
-	now := time.Now()
+	now := a.Now()
 	if now.Sub(a.ComboSystem.LastFoodTime) > a.ComboSystem.ComboTimeout {
 		a.ComboSystem.CurrentCombo = 0
 	} else {
@@ -257,38 +294,112 @@ func (a *Arena) updateCombo() {
 	a.ComboSystem.LastFoodTime = now
 }
 
-func (a *Arena) Tick(game *Game) bool {
-	if game.bonusActive && game.bonusType == "VELOCIDADE" {
-		// VELOCIDADE: anda 2 blocos por tick
-		a.Snake.Move()
-		a.Snake.Move()
-	} else {
-		a.Snake.Move()
+// aplica uma entrada do jogador antes do proximo tick
+func (a *Arena) ApplyInput(in Input) {
+	slot, in := splitPlayerInput(in)
+	if slot >= len(a.Players) {
+		return
 	}
-	head := a.Snake.Head()
 
-	// colisoes com arena
-	if head.X <= a.X || head.X >= a.X+a.Width-1 ||
-		head.Y <= a.Y || head.Y >= a.Y+a.Height-1 {
-		return false
+	// os outros jogadores so mexem a propria cobra, cheats so no jogador 1
+	if slot > 0 {
+		a.applyPlayerInput(a.Players[slot], in)
+		return
 	}
-	if a.Snake.SelfCollision() {
-		return false
+
+	switch in {
+	case INPUT_UP, INPUT_DOWN, INPUT_LEFT, INPUT_RIGHT, INPUT_QUIT:
+		a.applyPlayerInput(a.Players[0], in)
+
+	// cheats: suposto a bugs
+	case INPUT_CHEAT_GROW: // god mode
+		a.recordCheat("god")
+		a.Snake.Body = append(a.Snake.Body, a.Snake.Body[len(a.Snake.Body)-1])
+		a.Snake.Body = append(a.Snake.Body, a.Snake.Body[len(a.Snake.Body)-1])
+		a.AddMessage("god mode, isso e uma maldicao", 3*time.Second)
+	case INPUT_CHEAT_POINTS: // +1000 pontos instantaneos
+		a.recordCheat("pontos")
+		a.Points += 1000
+		a.AddMessage("adm desligado, voce recebeu +1000 pts", 3*time.Second)
+	case INPUT_CHEAT_LEVEL: // subir de nível
+		a.recordCheat("nivel")
+		a.Level += 5
+		a.increaseDifficulty()
+		a.AddMessage("voce recebeu uma dadiva! level +5", 3*time.Second)
+	case INPUT_CHEAT_BOSS: // spawn boss instantâneo
+		a.recordCheat("boss")
+		a.Bosses = append(a.Bosses, newBoss(a))
+		a.AddMessage("um bug foi encontrado, um estrangeiro apareceu", 4*time.Second)
+	case INPUT_CHEAT_KILL: // matar todos os bosses
+		a.recordCheat("kill")
+		for _, boss := range a.Bosses {
+			boss.IsAlive = false
+		}
+		a.AddMessage("uma bencao divina extinguiu os estrangeiros", 4*time.Second)
 	}
-	for _, obs := range a.Obstacles {
-		if head.X == obs.X && head.Y == obs.Y {
-			return false
+}
+
+// guarda cada cheat usado na partida uma unica vez
+func (a *Arena) recordCheat(name string) {
+	for _, c := range a.CheatsUsed {
+		if c == name {
+			return
+		}
+	}
+	a.CheatsUsed = append(a.CheatsUsed, name)
+}
+
+func (a *Arena) activateBonus(bonusType string) {
+	// nao ativa novo bonus se ja estiver ativo
+	if a.BonusActive {
+		return
+	}
+
+	a.BonusActive = true
+	a.BonusType = bonusType
+	a.bonusUntil = a.Now().Add(5 * time.Second)
+
+	switch bonusType {
+	case "CRESCIMENTO":
+		// e para crescer instantaneamente
+		for i := 0; i < 3; i++ {
+			a.Snake.Grow()
+		}
+	case "PONTOS":
+		a.Points += 50 // Bônus de pontos extra
+	}
+}
+
+func (a *Arena) Tick() bool {
+	a.moveObstacles()
+
+	prevHeads := make([]Coord, len(a.Players))
+	for i, p := range a.Players {
+		if !p.Alive {
+			continue
 		}
+		a.Player = p
+		prevHeads[i] = p.Snake.Head()
+		p.Ticks++
+		p.Alive = a.movePlayer()
+	}
+	a.resolvePlayerCollisions(prevHeads)
+	if a.matchOver() {
+		a.Player = a.Players[0]
+		return false
 	}
 
 	a.trySpawnBoss()
+	a.trySpawnPowerUp()
+	a.buildPathGrid()
 
 	for _, boss := range a.Bosses {
 		if !boss.IsAlive {
 			continue
 		}
 
-		boss.Move(head, a.Foods, a.Width, a.Height)
+		boss.Move(a)
+		boss.summon(a)
 
 		// estrangeiro come fruta
 		for j := len(a.Foods) - 1; j >= 0; j-- {
@@ -302,33 +413,10 @@ func (a *Arena) Tick(game *Game) bool {
 			}
 		}
 
-		// permitir para so perder pontos, tava muito apelativo ser hitkill
-		if a.Snake.CollidesWith(&Snake{Body: boss.Body}) {
-			if a.Points >= 50 {
-				a.Points -= 50
-			} else {
-				a.Points = 0
-			}
-			a.AddMessage("-50 pontos! Cuidado com o estrangeiro!", 2*time.Second)
-			// empurra o jogador
-			tail := a.Snake.Body[len(a.Snake.Body)-1]
-			a.Snake.Body = append(a.Snake.Body, tail)
-		}
-
-		// BATER NA CABEÇA DO BOSS = DANO
-		if head.X == boss.Head().X && head.Y == boss.Head().Y {
-			if boss.TakeDamage() {
-				grow := len(boss.Body) - 6
-				if grow < 3 {
-					grow = 3
-				}
-				for i := 0; i < grow; i++ {
-					a.Snake.Grow()
-				}
-				a.Points += boss.Points
-				a.AddMessage(fmt.Sprintf("ESTRANGEIRO DERROTADO! +%d pts +%d tamanho!", boss.Points, grow), 5*time.Second)
-			} else {
-				a.AddMessage(fmt.Sprintf("Dano no estrangeiro! (%d/3)", boss.Health), 2*time.Second)
+		for _, p := range a.Players {
+			if p.Alive && boss.IsAlive {
+				a.Player = p
+				a.bossContact(boss)
 			}
 		}
 	}
@@ -343,25 +431,151 @@ func (a *Arena) Tick(game *Game) bool {
 	a.Bosses = alive
 
 	// verifica comidas
+	eaten := false
+	for _, p := range a.Players {
+		if p.Alive {
+			a.Player = p
+			eaten = a.eatFoods() || eaten
+		}
+	}
+	a.Player = a.Players[0]
+
+	a.removeExpiredItems()
+	a.RemoveExpiredMessages()
+
+	if eaten || len(a.Foods) < a.maxFoods/2 {
+		a.placeFood()
+	}
+
+	return true
+}
+
+// move a cobra do jogador da vez e trata as batidas fatais, false se morreu
+func (a *Arena) movePlayer() bool {
+	if a.BonusActive && !a.Now().Before(a.bonusUntil) {
+		a.BonusActive = false
+		a.BonusType = ""
+	}
+
+	prevBody := append([]Coord(nil), a.Snake.Body...)
+	if a.BonusActive && a.BonusType == "VELOCIDADE" {
+		// VELOCIDADE: anda 2 blocos por tick
+		a.Snake.Move()
+		a.Snake.Move()
+	} else {
+		a.Snake.Move()
+	}
+	head := a.Snake.Head()
+
+	// colisoes com arena, corpo e obstaculos (fantasma atravessa os dois ultimos)
+	hitWall := a.isWall(head)
+	lethal := hitWall ||
+		(!a.GhostActive() && (a.Snake.SelfCollision() || a.isObstacle(head)))
+	if lethal {
+		if !a.Shield {
+			switch {
+			case hitWall:
+				a.DeathCause = DEATH_WALL
+			case a.Snake.SelfCollision():
+				a.DeathCause = DEATH_SELF
+			default:
+				a.DeathCause = DEATH_OBSTACLE
+			}
+			return false
+		}
+		a.Shield = false
+		a.AddMessage("O escudo absorveu o impacto!", 2*time.Second)
+		if hitWall {
+			// volta para dentro e vira para um lado livre
+			a.Snake.Body = prevBody
+			a.Snake.Dir = a.escapeDir()
+			head = a.Snake.Head()
+		}
+	}
+
+	a.collectPowerUps(head)
+	if a.MagnetActive() {
+		a.pullFoods(head)
+	}
+	return true
+}
+
+// golpe na cabeca do estrangeiro ou encostada no corpo dele
+func (a *Arena) bossContact(boss *Boss) {
+	head := a.Snake.Head()
+
+	// BATER NA CABEÇA DO BOSS = DANO
+	if head.X == boss.Head().X && head.Y == boss.Head().Y {
+		if boss.IsInvulnerable(a.Now()) {
+			return
+		}
+		if boss.TakeDamage(a.Now()) {
+			grow := len(boss.Body) - 6
+			if grow < 3 {
+				grow = 3
+			}
+			if boss.IsMinion {
+				grow = 1
+			}
+			for i := 0; i < grow; i++ {
+				a.Snake.Grow()
+			}
+			a.Points += boss.Points
+			if boss.IsMinion {
+				a.MinionsKilled++
+				a.AddMessage(fmt.Sprintf("Lacaio derrotado! +%d pts", boss.Points), 2*time.Second)
+			} else {
+				a.BossesKilled++
+				a.AddMessage(fmt.Sprintf("ESTRANGEIRO DERROTADO! +%d pts +%d tamanho!", boss.Points, grow), 5*time.Second)
+			}
+		} else {
+			a.AddMessage(fmt.Sprintf("Dano no estrangeiro! (%d/%d)", boss.Health, boss.MaxHealth), 2*time.Second)
+			boss.split(a)
+			if boss.Phase == 3 {
+				a.AddMessage("O estrangeiro ficou furioso!", 2*time.Second)
+			}
+		}
+		return
+	}
+
+	// permitir para so perder pontos, tava muito apelativo ser hitkill
+	if a.Snake.CollidesWith(&Snake{Body: boss.Body}) {
+		if a.Points >= 50 {
+			a.Points -= 50
+		} else {
+			a.Points = 0
+		}
+		a.AddMessage("-50 pontos! Cuidado com o estrangeiro!", 2*time.Second)
+		// empurra o jogador
+		tail := a.Snake.Body[len(a.Snake.Body)-1]
+		a.Snake.Body = append(a.Snake.Body, tail)
+	}
+}
+
+// frutas na cabeca do jogador da vez, true se comeu alguma
+func (a *Arena) eatFoods() bool {
+	head := a.Snake.Head()
 	eatenFoods := make([]*Food, 0)
 	remainingFoods := make([]*Food, 0)
 
 	for _, food := range a.Foods {
 		if head.X == food.X && head.Y == food.Y {
 			basePoints := food.Points
+			pointsBefore := a.Points
 
 			a.updateCombo()
 			comboMultiplier := 1 + (a.ComboSystem.CurrentCombo / 3)
 			finalPoints := basePoints * comboMultiplier
 
 			a.Points += finalPoints
+			a.FoodsEaten[food.FoodType]++
 
 			switch food.FoodType {
 			case FOOD_BONUS:
-				if !game.bonusActive {
+				if !a.BonusActive {
 					bonusTypes := []string{"VELOCIDADE", "CRESCIMENTO", "PONTOS"}
-					bonusType := bonusTypes[rand.Intn(len(bonusTypes))]
-					game.activateBonus(bonusType)
+					bonusType := bonusTypes[a.rng.Intn(len(bonusTypes))]
+					a.activateBonus(bonusType)
 				}
 				a.Snake.Grow()
 			case FOOD_PENALTY:
@@ -375,7 +589,7 @@ func (a *Arena) Tick(game *Game) bool {
 			eatenFoods = append(eatenFoods, food)
 
 			// aumentar dificuldade a cada 50 pontos
-			if a.Points/50 > (a.Points-finalPoints)/50 {
+			if a.Points/50 > pointsBefore/50 {
 				a.increaseDifficulty()
 			}
 		} else {
@@ -383,13 +597,5 @@ func (a *Arena) Tick(game *Game) bool {
 		}
 	}
 	a.Foods = remainingFoods
-
-	a.removeExpiredItems()
-	a.RemoveExpiredMessages()
-
-	if len(eatenFoods) > 0 || len(a.Foods) < a.maxFoods/2 {
-		a.placeFood()
-	}
-
-	return true
+	return len(eatenFoods) > 0
 }
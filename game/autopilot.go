@@ -0,0 +1,212 @@
+package game
+
+// Autopilot joga sozinho: olha a arena e devolve as mesmas entradas que o
+// teclado geraria, entao a partida dele vira replay e score como qualquer outra
+type Autopilot struct {
+	grid []bool
+}
+
+func NewAutopilot() *Autopilot {
+	return &Autopilot{}
+}
+
+var autopilotDirs = []Coord{{0, -1}, {0, 1}, {-1, 0}, {1, 0}}
+
+// entrada para o proximo tick, ou nada se a direcao atual ja serve
+func (p *Autopilot) Next(a *Arena) []Input {
+	dir := p.chooseDir(a)
+	if dir == a.Snake.Dir {
+		return nil
+	}
+	switch dir {
+	case Coord{0, -1}:
+		return []Input{INPUT_UP}
+	case Coord{0, 1}:
+		return []Input{INPUT_DOWN}
+	case Coord{-1, 0}:
+		return []Input{INPUT_LEFT}
+	default:
+		return []Input{INPUT_RIGHT}
+	}
+}
+
+func (p *Autopilot) chooseDir(a *Arena) Coord {
+	p.buildGrid(a)
+	head := a.Snake.Head()
+	back := Coord{}
+	if len(a.Snake.Body) > 1 {
+		back = Coord{X: a.Snake.Body[1].X - head.X, Y: a.Snake.Body[1].Y - head.Y}
+	}
+
+	// 1. caminho mais curto ate uma fruta boa (ou power-up), desde que
+	// depois de chegar la ainda sobre espaco para o corpo inteiro
+	var targets []Coord
+	for _, f := range a.Foods {
+		if f.FoodType != FOOD_PENALTY {
+			targets = append(targets, f.Coord)
+		}
+	}
+	for _, pu := range a.PowerUps {
+		targets = append(targets, pu.Coord)
+	}
+	if step, ok := p.bfs(a, head, back, targets); ok {
+		next := Coord{X: head.X + step.X, Y: head.Y + step.Y}
+		if p.floodFill(a, next) >= len(a.Snake.Body) {
+			return step
+		}
+	}
+
+	// 2. sem caminho seguro: vai para o lado com mais espaco livre
+	best, bestArea := a.Snake.Dir, -1
+	for _, d := range autopilotDirs {
+		if d == back {
+			continue
+		}
+		next := Coord{X: head.X + d.X, Y: head.Y + d.Y}
+		if p.blocked(a, next) {
+			continue
+		}
+		area := p.floodFill(a, next)
+		if area > bestArea || (area == bestArea && d == a.Snake.Dir) {
+			best, bestArea = d, area
+		}
+	}
+	return best
+}
+
+// celulas proibidas: paredes, obstaculos (e o proximo passo dos moveis),
+// o proprio corpo menos a cauda, as outras cobras, estrangeiros e a volta
+// da cabeca deles
+func (p *Autopilot) buildGrid(a *Arena) {
+	size := a.Width * a.Height
+	if len(p.grid) != size {
+		p.grid = make([]bool, size)
+	}
+	for i := range p.grid {
+		p.grid[i] = a.isWall(Coord{X: a.X + i%a.Width, Y: a.Y + i/a.Width})
+	}
+
+	mark := func(c Coord) {
+		if i, ok := a.gridIndex(c); ok {
+			p.grid[i] = true
+		}
+	}
+	for _, obs := range a.Obstacles {
+		mark(obs.Coord)
+		if obs.ObstacleType == OBSTACLE_MOVING && len(obs.Path) > 1 {
+			mark(obs.Path[(obs.PathIndex+1)%len(obs.Path)])
+			mark(obs.Path[(obs.PathIndex-1+len(obs.Path))%len(obs.Path)])
+		}
+	}
+	for _, seg := range a.Snake.Body[1 : len(a.Snake.Body)-1] {
+		mark(seg)
+	}
+	for _, other := range a.Players {
+		if other != a.Player && other.Alive {
+			for _, seg := range other.Snake.Body {
+				mark(seg)
+			}
+		}
+	}
+	for _, boss := range a.Bosses {
+		if !boss.IsAlive {
+			continue
+		}
+		for _, seg := range boss.Body {
+			mark(seg)
+		}
+		for _, d := range autopilotDirs {
+			mark(Coord{X: boss.Head().X + d.X, Y: boss.Head().Y + d.Y})
+		}
+	}
+}
+
+func (p *Autopilot) blocked(a *Arena, c Coord) bool {
+	i, ok := a.gridIndex(c)
+	return !ok || p.grid[i]
+}
+
+// primeiro passo do caminho mais curto ate o alvo mais proximo
+func (p *Autopilot) bfs(a *Arena, from, back Coord, targets []Coord) (Coord, bool) {
+	start, ok := a.gridIndex(from)
+	if !ok || len(targets) == 0 {
+		return Coord{}, false
+	}
+	isTarget := make(map[int]bool, len(targets))
+	for _, t := range targets {
+		if i, ok := a.gridIndex(t); ok && !p.grid[i] {
+			isTarget[i] = true
+		}
+	}
+
+	parent := make([]int, len(p.grid))
+	for i := range parent {
+		parent[i] = -1
+	}
+	parent[start] = start
+	queue := []int{start}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if isTarget[cur] && cur != start {
+			for parent[cur] != start {
+				cur = parent[cur]
+			}
+			return Coord{X: cur%a.Width + a.X - from.X, Y: cur/a.Width + a.Y - from.Y}, true
+		}
+
+		cx, cy := cur%a.Width, cur/a.Width
+		for _, d := range autopilotDirs {
+			// a cobra nao pode dar meia volta
+			if cur == start && d == back {
+				continue
+			}
+			nx, ny := cx+d.X, cy+d.Y
+			if nx < 0 || ny < 0 || nx >= a.Width || ny >= a.Height {
+				continue
+			}
+			next := ny*a.Width + nx
+			if parent[next] != -1 || p.grid[next] {
+				continue
+			}
+			parent[next] = cur
+			queue = append(queue, next)
+		}
+	}
+	return Coord{}, false
+}
+
+// quantas celulas livres da para alcancar a partir de c
+func (p *Autopilot) floodFill(a *Arena, c Coord) int {
+	start, ok := a.gridIndex(c)
+	if !ok || p.grid[start] {
+		return 0
+	}
+	seen := make([]bool, len(p.grid))
+	seen[start] = true
+	stack := []int{start}
+	count := 0
+
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		count++
+
+		cx, cy := cur%a.Width, cur/a.Width
+		for _, d := range autopilotDirs {
+			nx, ny := cx+d.X, cy+d.Y
+			if nx < 0 || ny < 0 || nx >= a.Width || ny >= a.Height {
+				continue
+			}
+			next := ny*a.Width + nx
+			if seen[next] || p.grid[next] {
+				continue
+			}
+			seen[next] = true
+			stack = append(stack, next)
+		}
+	}
+	return count
+}
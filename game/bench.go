@@ -0,0 +1,135 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// limite padrao de ticks por partida do autopilot (~10 minutos de jogo),
+// sem ele um bot bom nunca termina
+const AUTOPILOT_MAX_TICKS = 5000
+
+// joga uma partida inteira com o autopilot, sem terminal
+func PlayAutopilot(width, height int, seed int64, maxTicks int) *Simulation {
+	sim := NewSimulation(width, height, seed)
+	bot := NewAutopilot()
+	for sim.TickCount < maxTicks {
+		if !sim.Step(bot.Next(sim.Arena)...) {
+			break
+		}
+	}
+	return sim
+}
+
+// medias de N partidas do autopilot
+type BenchResult struct {
+	Games       int
+	AvgScore    float64
+	AvgLevel    float64
+	AvgSurvival time.Duration
+	BestScore   int
+	Survived    int // partidas que chegaram no limite de ticks vivas
+	Took        time.Duration
+}
+
+func (r BenchResult) String() string {
+	return fmt.Sprintf("%d partidas: score medio %.1f (melhor %d), nivel medio %.2f, sobrevivencia media %s, %d ate o limite (%s)",
+		r.Games, r.AvgScore, r.BestScore, r.AvgLevel, r.AvgSurvival.Round(time.Second), r.Survived, r.Took.Round(time.Millisecond))
+}
+
+// roda games partidas com seeds seed, seed+1, ... e tira as medias
+func RunBenchmark(games int, seed int64, maxTicks int) BenchResult {
+	start := time.Now()
+	res := BenchResult{Games: games}
+	if games <= 0 {
+		return res
+	}
+
+	var totalScore, totalLevel int
+	var totalTime time.Duration
+	for i := 0; i < games; i++ {
+		sim := PlayAutopilot(60, 25, seed+int64(i), maxTicks)
+		totalScore += sim.Arena.Points
+		totalLevel += sim.Arena.Level
+		totalTime += sim.Elapsed()
+		if sim.Arena.Points > res.BestScore {
+			res.BestScore = sim.Arena.Points
+		}
+		if sim.TickCount >= maxTicks {
+			res.Survived++
+		}
+	}
+
+	res.AvgScore = float64(totalScore) / float64(games)
+	res.AvgLevel = float64(totalLevel) / float64(games)
+	res.AvgSurvival = totalTime / time.Duration(games)
+	res.Took = time.Since(start)
+	return res
+}
+
+// resultado de um teste de carga contra o store
+type LoadTestResult struct {
+	Sent   int
+	Failed int
+	Took   time.Duration
+}
+
+func (r LoadTestResult) String() string {
+	rate := 0.0
+	if r.Took > 0 {
+		rate = float64(r.Sent) / r.Took.Seconds()
+	}
+	return fmt.Sprintf("%d scores enviados, %d falharam em %s (%.1f/s)",
+		r.Sent, r.Failed, r.Took.Round(time.Millisecond), rate)
+}
+
+// envia games scores jogados pelo autopilot, com replay, por workers
+// goroutines em paralelo. Cada worker assina como "bot-N" e os scores vao
+// marcados com Carga, para nao entrarem no ranking
+func RunLoadTest(store ScoreStore, games, workers int, seed int64, maxTicks int) LoadTestResult {
+	if workers < 1 {
+		workers = 1
+	}
+	start := time.Now()
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var res LoadTestResult
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			name := fmt.Sprintf("bot-%d", worker+1)
+			for i := range jobs {
+				sim := PlayAutopilot(60, 25, seed+int64(i), maxTicks)
+				replay := sim.Replay()
+				replay.Jogador = name
+				replay.Pontos = sim.Arena.Points
+				replay.Data = time.Now()
+
+				score := newScore(sim.Arena, 0, name, replay.Data)
+				score.Replay = replay
+				score.Carga = true
+				err := store.Save(score)
+
+				mu.Lock()
+				res.Sent++
+				if err != nil {
+					res.Failed++
+				}
+				mu.Unlock()
+			}
+		}(w)
+	}
+
+	for i := 0; i < games; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	res.Took = time.Since(start)
+	return res
+}
@@ -1,41 +1,73 @@
 package game
 
 import (
+	"fmt"
 	"math"
-	"math/rand"
+	"strings"
 	"time"
+
+	"github.com/nsf/termbox-go"
 )
 
-func newBoss(arenaWidth, arenaHeight int, playerSnake *Snake) *Boss {
-	side := rand.Intn(4)
+// vida do estrangeiro por nivel: a partir de MinLevel usa Health
+var bossHealthTable = []struct{ MinLevel, Health int }{
+	{0, 3},
+	{10, 4},
+	{15, 5},
+	{20, 6},
+}
+
+// tempo sem levar dano depois de cada golpe
+const BOSS_INVULNERABLE = 1500 * time.Millisecond
+
+// intervalo entre lacaios/obstaculos criados na fase 3
+const BOSS_SUMMON_INTERVAL = 6 * time.Second
+
+func bossHealthForLevel(level int) int {
+	health := bossHealthTable[0].Health
+	for _, entry := range bossHealthTable {
+		if level >= entry.MinLevel {
+			health = entry.Health
+		}
+	}
+	return health
+}
+
+func newBoss(a *Arena) *Boss {
+	kind := chooseBossKind(a)
+	species := bossSpeciesTable[kind]
+	arenaWidth, arenaHeight := a.Width, a.Height
+	rng := a.rng
+
+	side := rng.Intn(4)
 	var head Coord
 
 	switch side {
 	case 0: // esquerda
-		head = Coord{X: 3, Y: rand.Intn(arenaHeight-8) + 5}
+		head = Coord{X: 3, Y: rng.Intn(arenaHeight-8) + 5}
 	case 1: // direita
-		head = Coord{X: arenaWidth - 4, Y: rand.Intn(arenaHeight-8) + 5}
+		head = Coord{X: arenaWidth - 4, Y: rng.Intn(arenaHeight-8) + 5}
 	case 2: // cima
-		head = Coord{X: rand.Intn(arenaWidth-8) + 5, Y: 4}
+		head = Coord{X: rng.Intn(arenaWidth-8) + 5, Y: 4}
 	default: // baixo
-		head = Coord{X: rand.Intn(arenaWidth-8) + 5, Y: arenaHeight - 5}
+		head = Coord{X: rng.Intn(arenaWidth-8) + 5, Y: arenaHeight - 5}
 	}
 
 	// prevencao para nao nascer em cima do jogador
 	for i := 0; i < 30; i++ {
-		if !playerSnake.IsOnPosition(head) && !playerSnake.IsOnPosition(Coord{head.X + 1, head.Y}) {
+		if !a.isSnake(head) && !a.isSnake(Coord{head.X + 1, head.Y}) {
 			break
 		}
 		// tenta outra posição na mesma borda
 		switch side {
 		case 0:
-			head.Y = rand.Intn(arenaHeight-8) + 5
+			head.Y = rng.Intn(arenaHeight-8) + 5
 		case 1:
-			head.Y = rand.Intn(arenaHeight-8) + 5
+			head.Y = rng.Intn(arenaHeight-8) + 5
 		case 2:
-			head.X = rand.Intn(arenaWidth-8) + 5
+			head.X = rng.Intn(arenaWidth-8) + 5
 		case 3:
-			head.X = rand.Intn(arenaWidth-8) + 5
+			head.X = rng.Intn(arenaWidth-8) + 5
 		}
 	}
 
@@ -54,80 +86,135 @@ func newBoss(arenaWidth, arenaHeight int, playerSnake *Snake) *Boss {
 
 	// body inicial
 	body := []Coord{head}
-	for i := 1; i < 9; i++ {
+	for i := 1; i < species.Length; i++ {
 		body = append(body, Coord{
 			X: head.X - dir.X*i,
 			Y: head.Y - dir.Y*i,
 		})
 	}
 
+	health := bossHealthForLevel(a.Level)
 	return &Boss{
-		Body:     body,
-		Dir:      dir,
-		Speed:    160 * time.Millisecond,
-		LastMove: time.Now(),
-		Points:   250,
-		IsAlive:  true,
-		Health:   1, // vida inicial
+		Body:       body,
+		Dir:        dir,
+		Speed:      species.Speed,
+		LastMove:   a.Now(),
+		Points:     species.Points,
+		IsAlive:    true,
+		Health:     health,
+		MaxHealth:  health,
+		Phase:      1,
+		Kind:       kind,
+		baseSpeed:  species.Speed,
+		lastSummon: a.Now(),
 	}
 }
 
+// lacaio: cobrinha de 4 gomos que so persegue o jogador e morre com um golpe
+func newMinion(a *Arena, at Coord) *Boss {
+	return &Boss{
+		Body:      []Coord{at, at, at, at},
+		Dir:       Coord{X: 1},
+		Speed:     140 * time.Millisecond,
+		LastMove:  a.Now(),
+		Points:    50,
+		IsAlive:   true,
+		Health:    1,
+		MaxHealth: 1,
+		Phase:     3,
+		IsMinion:  true,
+		baseSpeed: 140 * time.Millisecond,
+	}
+}
+
+// fase pela vida restante: acima de 2/3 calmo, acima de 1/3 rapido, depois agressivo
+func (b *Boss) updatePhase() {
+	switch {
+	case b.IsMinion:
+		return
+	case b.Health*3 > b.MaxHealth*2:
+		b.Phase = 1
+		b.Speed = b.baseSpeed
+	case b.Health*3 > b.MaxHealth:
+		b.Phase = 2
+		b.Speed = b.baseSpeed * 3 / 4
+	default:
+		b.Phase = 3
+		b.Speed = b.baseSpeed * 3 / 5
+	}
+}
+
+// fase 3: de tempos em tempos solta um lacaio ou um obstaculo temporario
+func (b *Boss) summon(a *Arena) {
+	if b.Phase < 3 || b.IsMinion || a.Now().Sub(b.lastSummon) < BOSS_SUMMON_INTERVAL {
+		return
+	}
+	b.lastSummon = a.Now()
+	tail := b.Body[len(b.Body)-1]
+
+	if a.rng.Intn(2) == 0 {
+		a.Bosses = append(a.Bosses, newMinion(a, tail))
+		a.AddMessage("O estrangeiro chamou um lacaio!", 2*time.Second)
+		return
+	}
+	if !a.isWall(tail) && !a.isSnake(tail) && !a.isObstacle(tail) {
+		a.Obstacles = append(a.Obstacles, &Obstacle{
+			Coord:        tail,
+			ObstacleType: OBSTACLE_WALL,
+			IsTemporary:  true,
+			SpawnTime:    a.Now(),
+			Lifetime:     8 * time.Second,
+		})
+	}
+}
+
+func (b *Boss) IsInvulnerable(now time.Time) bool {
+	return now.Before(b.InvulnerableUntil)
+}
+
 // IA do estrangeiro
-func (b *Boss) calculateDirection(playerHead Coord, foods []*Food, arenaWidth, arenaHeight int) Coord {
+func (b *Boss) calculateDirection(a *Arena) Coord {
 	head := b.Body[0]
+	playerHead := a.nearestPlayerHead(head)
+	foods := a.Foods
 
-	// TODO: 1. PRIORIDADE MAXIMA: ir atras da fruta mais proxima
-	var closestFood *Food
-	bestDist := 999.0
+	// pedaco de divisor ainda fora da arena: entra primeiro
+	if a.isWall(head) {
+		return greedyStep(head, Coord{X: a.X + a.Width/2, Y: a.Y + a.Height/2})
+	}
+
+	// fase 3, lacaios e cacadores: so querem o jogador
+	if b.Phase >= 3 || b.Kind == BOSS_HUNTER {
+		return b.stepToward(a, []Coord{playerHead})
+	}
+
+	// construtor nao liga para frutas nem para o jogador, so anda e constroi
+	if b.Kind == BOSS_BUILDER {
+		foods = nil
+		playerHead = Coord{X: -100, Y: -100}
+	}
+
+	// vai atras das frutas que estiverem a ate 20 blocos de distancia
+	var targets []Coord
 	for _, f := range foods {
 		dx := float64(f.X - head.X)
 		dy := float64(f.Y - head.Y)
-		dist := math.Sqrt(dx*dx + dy*dy)
-		if dist < bestDist {
-			bestDist = dist
-			closestFood = f
+		if math.Sqrt(dx*dx+dy*dy) < 20 {
+			targets = append(targets, f.Coord)
 		}
 	}
-
-	// vai atras da fruta se estiver a ate 20 blocos de distancia
-	if closestFood != nil && bestDist < 20 {
-		dx := closestFood.X - head.X
-		dy := closestFood.Y - head.Y
-
-		if math.Abs(float64(dx)) > math.Abs(float64(dy)) {
-			if dx > 0 {
-				return Coord{1, 0}
-			}
-			return Coord{-1, 0}
-		} else {
-			if dy > 0 {
-				return Coord{0, 1}
-			}
-			return Coord{0, -1}
-		}
+	if len(targets) > 0 {
+		return b.stepToward(a, targets)
 	}
 
 	// 2. so persegue jogador se estiver MUITO PERTO, menos de 6 blocos
-	dxP := playerHead.X - head.X
-	dyP := playerHead.Y - head.Y
-	distToPlayer := math.Abs(float64(dxP)) + math.Abs(float64(dyP))
-	if distToPlayer < 6 {
-		if math.Abs(float64(dxP)) > math.Abs(float64(dyP)) {
-			if dxP > 0 {
-				return Coord{1, 0}
-			}
-			return Coord{-1, 0}
-		} else {
-			if dyP > 0 {
-				return Coord{0, 1}
-			}
-			return Coord{0, -1}
-		}
+	if abs(playerHead.X-head.X)+abs(playerHead.Y-head.Y) < 6 {
+		return b.stepToward(a, []Coord{playerHead})
 	}
 
 	// 3. random moviment se estiver longe
 	directions := []Coord{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
-	rand.Shuffle(len(directions), func(i, j int) {
+	a.rng.Shuffle(len(directions), func(i, j int) {
 		directions[i], directions[j] = directions[j], directions[i]
 	})
 
@@ -135,9 +222,7 @@ func (b *Boss) calculateDirection(playerHead Coord, foods []*Food, arenaWidth, a
 		if d == (Coord{-b.Dir.X, -b.Dir.Y}) {
 			continue
 		}
-		nx := head.X + d.X
-		ny := head.Y + d.Y
-		if nx >= 3 && nx <= arenaWidth-4 && ny >= 3 && ny <= arenaHeight-4 {
+		if !a.bossBlocked(Coord{X: head.X + d.X, Y: head.Y + d.Y}) {
 			return d
 		}
 	}
@@ -145,24 +230,50 @@ func (b *Boss) calculateDirection(playerHead Coord, foods []*Food, arenaWidth, a
 	return b.Dir // fica parado se encurralado (raro)
 }
 
-func (b *Boss) Move(playerHead Coord, foods []*Food, arenaWidth, arenaHeight int) {
-	if time.Since(b.LastMove) < b.Speed || !b.IsAlive {
+// primeiro passo do caminho mais curto ate o alvo mais proximo. Sem caminho
+// (ou sem orcamento de busca neste tick) vai no greedy, evitando bloqueios
+func (b *Boss) stepToward(a *Arena, targets []Coord) Coord {
+	if step, ok := a.findPath(b.Head(), targets); ok {
+		return step
+	}
+
+	head := b.Head()
+	best := b.Dir
+	bestDist := -1
+	for _, d := range []Coord{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+		next := Coord{X: head.X + d.X, Y: head.Y + d.Y}
+		if a.bossBlocked(next) {
+			continue
+		}
+		dist := abs(targets[0].X-next.X) + abs(targets[0].Y-next.Y)
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = d, dist
+		}
+	}
+	return best
+}
+
+func (b *Boss) Move(a *Arena) {
+	if a.Now().Sub(b.LastMove) < b.Speed || !b.IsAlive {
 		return
 	}
 
-	b.Dir = b.calculateDirection(playerHead, foods, arenaWidth, arenaHeight)
+	b.Dir = b.calculateDirection(a)
 	newHead := Coord{X: b.Head().X + b.Dir.X, Y: b.Head().Y + b.Dir.Y}
 
-	// security contra parede
-	if newHead.X <= 2 || newHead.X >= arenaWidth-3 || newHead.Y <= 2 || newHead.Y >= arenaHeight-3 {
-		// tenta outra direcao
-		b.Dir = b.calculateDirection(playerHead, foods, arenaWidth, arenaHeight)
-		newHead = Coord{X: b.Head().X + b.Dir.X, Y: b.Head().Y + b.Dir.Y}
+	// security contra parede, obstaculos e corpos: na duvida espera
+	if !a.isWall(b.Head()) && a.bossBlocked(newHead) {
+		b.LastMove = a.Now()
+		return
 	}
 
+	vacated := b.Body[len(b.Body)-1]
 	b.Body = append([]Coord{newHead}, b.Body...)
 	b.Body = b.Body[:len(b.Body)-1]
-	b.LastMove = time.Now()
+	b.LastMove = a.Now()
+	b.moves++
+	b.buildWall(a, vacated)
+	a.markPathGrid(vacated, newHead)
 }
 
 func (b *Boss) Head() Coord {
@@ -175,12 +286,28 @@ func (b *Boss) Grow() {
 	b.Points += 20 // cresce = mais pontos ao morrer
 }
 
-func (b *Boss) TakeDamage() (died bool) {
+// greedy pelo eixo com maior distancia ate o alvo
+func greedyStep(from, to Coord) Coord {
+	dx := to.X - from.X
+	dy := to.Y - from.Y
+	if abs(dx) > abs(dy) {
+		return Coord{X: sign(dx)}
+	}
+	if dy == 0 {
+		return Coord{X: 1}
+	}
+	return Coord{Y: sign(dy)}
+}
+
+// tira uma vida e deixa o estrangeiro invulneravel por um instante
+func (b *Boss) TakeDamage(now time.Time) (died bool) {
 	b.Health--
 	if b.Health <= 0 {
 		b.IsAlive = false
 		return true
 	}
+	b.InvulnerableUntil = now.Add(BOSS_INVULNERABLE)
+	b.updatePhase()
 	return false
 }
 
@@ -192,3 +319,38 @@ func (b *Boss) IsOnPosition(c Coord) bool {
 	}
 	return false
 }
+
+// barra de vida do estrangeiro mais ferido, no canto inferior direito
+func (g *Game) drawBossHealth() {
+	var target *Boss
+	others := 0
+	for _, b := range g.arena.Bosses {
+		if !b.IsAlive || b.IsMinion {
+			continue
+		}
+		if target == nil || b.Health < target.Health {
+			if target != nil {
+				others++
+			}
+			target = b
+		} else {
+			others++
+		}
+	}
+	if target == nil {
+		return
+	}
+
+	bar := strings.Repeat("█", target.Health) + strings.Repeat("░", target.MaxHealth-target.Health)
+	text := fmt.Sprintf("%s %s F%d", target.Species().Name, bar, target.Phase)
+	if others > 0 {
+		text += fmt.Sprintf(" +%d", others)
+	}
+
+	color := termbox.ColorRed
+	if target.Phase == 3 {
+		color = termbox.ColorRed | termbox.AttrBold
+	}
+	g.drawText(g.arena.X+g.arena.Width-len([]rune(text))-4, g.arena.Y+g.arena.Height+2,
+		color, termbox.ColorDefault, text)
+}
@@ -0,0 +1,121 @@
+package game
+
+import (
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// especies de estrangeiro
+const (
+	BOSS_THIEF = iota
+	BOSS_HUNTER
+	BOSS_BUILDER
+	BOSS_SPLITTER
+)
+
+// o que muda de uma especie para outra. MinLevel e Weight decidem quem
+// pode aparecer em cada nivel e com que frequencia
+type bossSpecies struct {
+	Name     string
+	MinLevel int
+	Weight   int
+	Points   int
+	Length   int
+	Speed    time.Duration
+	Glyph    rune
+	Color    termbox.Attribute
+}
+
+var bossSpeciesTable = []bossSpecies{
+	BOSS_THIEF: {
+		Name: "Ladrao", MinLevel: 3, Weight: 4, Points: 250, Length: 9,
+		Speed: 160 * time.Millisecond, Glyph: '■', Color: termbox.ColorRed,
+	},
+	BOSS_HUNTER: {
+		Name: "Cacador", MinLevel: 6, Weight: 3, Points: 300, Length: 7,
+		Speed: 180 * time.Millisecond, Glyph: '◘', Color: termbox.ColorLightMagenta,
+	},
+	BOSS_BUILDER: {
+		Name: "Construtor", MinLevel: 8, Weight: 2, Points: 350, Length: 8,
+		Speed: 200 * time.Millisecond, Glyph: '▒', Color: termbox.ColorYellow,
+	},
+	BOSS_SPLITTER: {
+		Name: "Divisor", MinLevel: 12, Weight: 2, Points: 400, Length: 12,
+		Speed: 170 * time.Millisecond, Glyph: '◉', Color: termbox.ColorLightGreen,
+	},
+}
+
+// sorteia, pelos pesos, uma especie liberada no nivel atual
+func chooseBossKind(a *Arena) int {
+	total := 0
+	for _, s := range bossSpeciesTable {
+		if a.Level >= s.MinLevel {
+			total += s.Weight
+		}
+	}
+	if total == 0 {
+		return BOSS_THIEF
+	}
+
+	pick := a.rng.Intn(total)
+	for kind, s := range bossSpeciesTable {
+		if a.Level < s.MinLevel {
+			continue
+		}
+		if pick < s.Weight {
+			return kind
+		}
+		pick -= s.Weight
+	}
+	return BOSS_THIEF
+}
+
+func (b *Boss) Species() bossSpecies {
+	return bossSpeciesTable[b.Kind]
+}
+
+// construtor: a cada 4 passos deixa um bloco temporario no rastro
+func (b *Boss) buildWall(a *Arena, vacated Coord) {
+	if b.Kind != BOSS_BUILDER || b.moves%4 != 0 {
+		return
+	}
+	head := a.nearestPlayerHead(vacated)
+	if a.isWall(vacated) || !a.isPositionValid(vacated) || abs(vacated.X-head.X)+abs(vacated.Y-head.Y) < 3 {
+		return
+	}
+	a.Obstacles = append(a.Obstacles, &Obstacle{
+		Coord:        vacated,
+		ObstacleType: OBSTACLE_WALL,
+		IsTemporary:  true,
+		SpawnTime:    a.Now(),
+		Lifetime:     6 * time.Second,
+	})
+}
+
+// divisor: ao levar um golpe a metade de tras vira outro estrangeiro, com a
+// mesma vida restante. Pedacos pequenos demais nao se dividem mais
+func (b *Boss) split(a *Arena) {
+	if b.Kind != BOSS_SPLITTER || len(b.Body) < 6 {
+		return
+	}
+
+	half := len(b.Body) / 2
+	tail := make([]Coord, 0, len(b.Body)-half)
+	for i := len(b.Body) - 1; i >= half; i-- {
+		tail = append(tail, b.Body[i])
+	}
+	b.Body = b.Body[:half]
+
+	b.Points /= 2
+	if b.Points < 100 {
+		b.Points = 100
+	}
+
+	piece := *b
+	piece.Body = tail
+	// a cauda pode estar fora da arena logo depois de nascer: vai para o centro
+	piece.Dir = greedyStep(tail[0], Coord{X: a.X + a.Width/2, Y: a.Y + a.Height/2})
+	a.Bosses = append(a.Bosses, &piece)
+	a.AddMessage("O Divisor se partiu em dois!", 2*time.Second)
+}
@@ -0,0 +1,256 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// conexao do cliente com o servidor: mensagens recebidas chegam em msgs,
+// que fecha quando a conexao cai
+type serverConn struct {
+	conn net.Conn
+	msgs chan NetMessage
+	mu   sync.Mutex
+	seq  int
+}
+
+// conecta e manda a primeira mensagem (entrar no lobby ou assistir)
+func dialServer(addr string, hello NetMessage) (*serverConn, error) {
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	sc := &serverConn{conn: conn, msgs: make(chan NetMessage, 64)}
+	go func() {
+		defer close(sc.msgs)
+		readNetMessages(conn, func(msg NetMessage) bool {
+			sc.msgs <- msg
+			return true
+		})
+	}()
+	if err := sc.send(hello); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return sc, nil
+}
+
+func (sc *serverConn) send(msg NetMessage) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	_, err := sc.conn.Write(encodeNetMessage(msg))
+	return err
+}
+
+func (sc *serverConn) sendInput(in Input) error {
+	sc.mu.Lock()
+	sc.seq++
+	seq := sc.seq
+	sc.mu.Unlock()
+	return sc.send(NetMessage{Type: MSG_INPUT, Seq: seq, Dir: netDirName(in)})
+}
+
+func (sc *serverConn) ping() error {
+	return sc.send(NetMessage{Type: MSG_PING, Time: time.Now().UnixNano()})
+}
+
+func (sc *serverConn) Close() error {
+	return sc.conn.Close()
+}
+
+// joga no servidor: as setas vao para la e a tela desenha os snapshots
+func (g *Game) playOnline(addr string) error {
+	sc, err := dialServer(addr, NetMessage{Type: MSG_JOIN, Name: g.userID})
+	if err != nil {
+		return err
+	}
+	defer sc.Close()
+
+	g.arena = newMirrorArena()
+	g.status = ""
+	slot := -1
+	playing := false
+	var rtt time.Duration
+	lastState := time.Now()
+	g.drawNetMessage("Conectado a "+addr, "Entrando no lobby...")
+
+	ping := time.NewTicker(time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case ev := <-g.events:
+			if ev.Type != termbox.EventKey {
+				continue
+			}
+			switch ev.Key {
+			case termbox.KeyEsc, termbox.KeyCtrlC:
+				return nil
+			case termbox.KeyEnter:
+				if !playing {
+					sc.send(NetMessage{Type: MSG_JOIN, Name: g.userID})
+				}
+			case termbox.KeyArrowUp:
+				sc.sendInput(INPUT_UP)
+			case termbox.KeyArrowDown:
+				sc.sendInput(INPUT_DOWN)
+			case termbox.KeyArrowLeft:
+				sc.sendInput(INPUT_LEFT)
+			case termbox.KeyArrowRight:
+				sc.sendInput(INPUT_RIGHT)
+			}
+
+		case msg, ok := <-sc.msgs:
+			if !ok {
+				return errors.New("conexao com o servidor caiu")
+			}
+			switch msg.Type {
+			case MSG_LOBBY:
+				if !playing {
+					g.drawNetMessage("LOBBY",
+						fmt.Sprintf("Aguardando jogadores (%d/%d): %s", len(msg.Lobby), msg.Needed, strings.Join(msg.Lobby, ", ")))
+				}
+			case MSG_START:
+				slot = msg.Slot
+				playing = true
+				g.arena = newMirrorArena()
+			case MSG_STATE:
+				if msg.State == nil {
+					// servidor com defeito ou outro programa: ignora
+					continue
+				}
+				lastState = time.Now()
+				msg.State.applyTo(g.arena, slot)
+				g.score = g.arena.Points
+				g.spectators = msg.State.Spectators
+				sc.mu.Lock()
+				pending := sc.seq - msg.Seq
+				sc.mu.Unlock()
+				g.status = fmt.Sprintf("Online • ping %dms • entradas em transito %d", rtt.Milliseconds(), pending)
+				g.drawGame()
+			case MSG_OVER:
+				playing = false
+				g.drawOnlineResults(msg.Results, slot)
+			case MSG_PONG:
+				rtt = time.Since(time.Unix(0, msg.Time))
+			case MSG_ERROR:
+				g.status = "Servidor: " + msg.Error
+			}
+
+		case <-ping.C:
+			if err := sc.ping(); err != nil {
+				return err
+			}
+			// servidor parado no meio da partida: avisa sem fechar a tela
+			if playing && time.Since(lastState) > 2*time.Second {
+				g.status = fmt.Sprintf("Sem resposta do servidor ha %ds...", int(time.Since(lastState).Seconds()))
+				g.drawGame()
+			}
+		}
+	}
+}
+
+// tela simples de titulo + linha, usada no lobby
+func (g *Game) drawNetMessage(title, text string) {
+	g.r.Clear()
+	width, height := g.r.Size()
+	g.drawText((width-len([]rune(title)))/2, height/2-2, termbox.ColorGreen|termbox.AttrBold, termbox.ColorDefault, title)
+	g.drawText((width-len([]rune(text)))/2, height/2, termbox.ColorWhite, termbox.ColorDefault, text)
+	hint := "ESC sai"
+	g.drawText((width-len(hint))/2, height-2, termbox.ColorDarkGray, termbox.ColorDefault, hint)
+	g.r.Flush()
+}
+
+func (g *Game) drawOnlineResults(results []Score, slot int) {
+	g.r.Clear()
+	width, height := g.r.Size()
+
+	title := "FIM DA PARTIDA ONLINE"
+	g.drawText((width-len(title))/2, height/2-4, termbox.ColorRed|termbox.AttrBold, termbox.ColorDefault, title)
+
+	for i, s := range results {
+		line := fmt.Sprintf("%-20s %6d pts", s.Nome, s.Pontos)
+		color := termbox.ColorWhite
+		if s.Slot == slot {
+			color = termbox.ColorYellow | termbox.AttrBold
+		}
+		g.drawText((width-len([]rune(line)))/2, height/2-2+i, color, termbox.ColorDefault, line)
+	}
+
+	hint := "ENTER joga de novo • ESC sai"
+	if g.watching {
+		hint = "Aguardando a proxima partida • ESC sai"
+	}
+	g.drawText((width-len(hint))/2, height-3, termbox.ColorGreen, termbox.ColorDefault, hint)
+	g.r.Flush()
+}
+
+// RunBotClient conecta um autopilot sem terminal ao servidor e joga
+// matches partidas (0 = para sempre). Serve para testar o servidor com
+// varios clientes no loopback
+func RunBotClient(addr, name string, matches int) error {
+	sc, err := dialServer(addr, NetMessage{Type: MSG_JOIN, Name: name})
+	if err != nil {
+		return err
+	}
+	defer sc.Close()
+
+	arena := newMirrorArena()
+	bot := NewAutopilot()
+	slot := -1
+	played := 0
+	lastSent := Input(-1)
+	ping := time.NewTicker(time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case msg, ok := <-sc.msgs:
+			if !ok {
+				return errors.New("conexao com o servidor caiu")
+			}
+			switch msg.Type {
+			case MSG_START:
+				slot = msg.Slot
+				arena = newMirrorArena()
+				lastSent = -1
+			case MSG_STATE:
+				if msg.State == nil {
+					continue
+				}
+				msg.State.applyTo(arena, slot)
+				if !arena.Alive {
+					continue
+				}
+				// a resposta do servidor atrasa: nao repete a direcao que
+				// ainda esta a caminho
+				sc.mu.Lock()
+				pending := sc.seq - msg.Seq
+				sc.mu.Unlock()
+				for _, in := range bot.Next(arena) {
+					if in != lastSent || pending == 0 {
+						sc.sendInput(in)
+						lastSent = in
+					}
+				}
+			case MSG_OVER:
+				played++
+				if matches > 0 && played >= matches {
+					return nil
+				}
+				sc.send(NetMessage{Type: MSG_JOIN, Name: name})
+			}
+		case <-ping.C:
+			if err := sc.ping(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package game
+
+import (
+	"net"
+	"testing"
+)
+
+// estado vazio de um servidor com defeito e ignorado, nao derruba o bot
+func TestBotClientIgnoresEmptyState(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write(encodeNetMessage(NetMessage{Type: MSG_START}))
+		conn.Write(encodeNetMessage(NetMessage{Type: MSG_STATE}))
+	}()
+
+	// o servidor fecha depois do estado vazio: o bot tem que sair com erro
+	if err := RunBotClient(ln.Addr().String(), "bot", 1); err == nil {
+		t.Error("RunBotClient terminou sem erro com a conexao fechada")
+	}
+}
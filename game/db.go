@@ -3,8 +3,9 @@ package game
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
-	"os"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,105 +13,343 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type Score struct {
-	Nome   string    `bson:"nome"`
-	Pontos int       `bson:"pontos"`
-	Data   time.Time `bson:"data"`
+// ScoreStore gravado no replica set do MongoDB
+type MongoStore struct {
+	cfg     MongoConfig
+	client  *mongo.Client
+	db      *mongo.Database
+	scores  *mongo.Collection
+	players *mongo.Collection
 }
 
-var (
-	scoresCollection *mongo.Collection
-	isDocker         bool
-	client           *mongo.Client // mantido para desconexão futura se precisar
-)
+// cria o cliente sem esperar o replica set, veja WaitReady
+func NewMongoStore(cfg MongoConfig) (*MongoStore, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	client, err := mongo.Connect(context.Background(), cfg.clientOptions())
+	if err != nil {
+		return nil, err
+	}
+	db := client.Database("trabalho")
+	return &MongoStore{
+		cfg:     cfg,
+		client:  client,
+		db:      db,
+		scores:  db.Collection("snake_scores"),
+		players: db.Collection("players"),
+	}, nil
+}
 
-func initDB() {
-	if os.Getenv("MONGO_URI") != "" || os.Getenv("DOCKER_ENV") != "" {
-		isDocker = true
-		uri := os.Getenv("MONGO_URI")
-		if uri == "" {
-			uri = "mongodb://mongo1:27017,mongo2:27017,mongo3:27017/trabalho?replicaSet=rs0&connect=direct"
+// espera o replica set responder, com as tentativas e o intervalo da
+// config, mas nunca mais que ConnectWait no total: com o banco fora do ar o
+// jogo abre assim mesmo e a fila local guarda os scores
+func (m *MongoStore) WaitReady() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.ConnectWait)
+	defer cancel()
+
+	attempts := m.cfg.ConnectRetries
+	for i := 0; i < attempts; i++ {
+		pingCtx, cancelPing := context.WithTimeout(ctx, m.cfg.ConnectTimeout)
+		err := m.client.Ping(pingCtx, nil)
+		cancelPing()
+		if err == nil {
+			log.Println("MongoDB Replica Set conectado com sucesso!")
+			return nil
+		}
+		log.Printf("Aguardando MongoDB... (tentativa %d/%d) - erro: %v", i+1, attempts, err)
+		select {
+		case <-time.After(m.cfg.RetryInterval):
+		case <-ctx.Done():
+			return fmt.Errorf("MongoDB não disponível após %s", m.cfg.ConnectWait)
 		}
-		connectWithRetry(uri)
-	} else {
-		log.Println("Modo local detectado — scores serão salvos apenas na sessão (sem MongoDB)")
 	}
+	return fmt.Errorf("MongoDB não disponível após %d tentativas", attempts)
 }
 
-func connectWithRetry(uri string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (m *MongoStore) Save(s Score) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.WriteTimeout)
 	defer cancel()
 
-	var err error
-	client, err = mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	_, err := m.scores.InsertOne(ctx, s)
+	if mongo.IsDuplicateKeyError(err) {
+		// ja foi enviado antes (reenvio da fila local)
+		return nil
+	}
+	// erro no proprio documento (o validador, codigo 121, por exemplo):
+	// mandar de novo nao adianta, ao contrario de queda ou timeout
+	var we mongo.WriteException
+	if errors.As(err, &we) && len(we.WriteErrors) > 0 {
+		return fmt.Errorf("%w: %v", ErrScoreRejected, err)
+	}
 	if err != nil {
-		log.Fatalf("Falha ao criar cliente MongoDB: %v", err)
+		return err
 	}
+	log.Printf("Score salvo com sucesso: %s — %d pontos", s.Nome, s.Pontos)
+	return nil
+}
 
-	for i := 0; i < 30; i++ {
-		if err = client.Ping(ctx, nil); err == nil {
-			scoresCollection = client.Database("trabalho").Collection("snake_scores")
-			log.Println("MongoDB Replica Set conectado com sucesso!")
-			return
+// filtro comum do Top e do PlayerRank
+func scoreFilter(q ScoreQuery) bson.M {
+	filter := bson.M{}
+	if !q.IncludeCheated {
+		for k, v := range rankableFilter {
+			filter[k] = v
 		}
-		log.Printf("Aguardando MongoDB... (tentativa %d/30) - erro: %v", i+1, err)
-		time.Sleep(2 * time.Second)
 	}
+	if q.Player != "" {
+		filter["nome"] = q.Player
+	}
+	if !q.Since.IsZero() {
+		filter["data"] = bson.M{"$gte": q.Since}
+	}
+	return filter
+}
 
-	log.Println("MongoDB não disponível após 30 tentativas — scores não serão persistidos")
-	scoresCollection = nil
+// melhor score de cada jogador (o perfil, ou o nome nos scores sem perfil),
+// ainda na ordem do ranking
+func bestPerPlayerStages(filter bson.M) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: "pontos", Value: -1}, {Key: "data", Value: 1}}}},
+		{{Key: "$project", Value: bson.M{"replay": 0}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$ifNull": bson.A{"$jogador_id", "$nome"}},
+			"score": bson.M{"$first": "$$ROOT"},
+		}}},
+		{{Key: "$replaceWith", Value: "$score"}},
+		{{Key: "$sort", Value: bson.D{{Key: "pontos", Value: -1}, {Key: "data", Value: 1}}}},
+	}
 }
 
-func SaveScore(name string, points int) {
-	if scoresCollection == nil || !isDocker {
-		log.Printf("Score local: %s — %d pontos", name, points)
-		return
+func (m *MongoStore) Top(q ScoreQuery) ([]Score, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.ReadTimeout)
+	defer cancel()
+
+	filter := scoreFilter(q)
+	var cursor *mongo.Cursor
+	var err error
+	if q.BestPerPlayer && !q.Recent {
+		pipeline := append(bestPerPlayerStages(filter),
+			bson.D{{Key: "$skip", Value: q.Offset}},
+			bson.D{{Key: "$limit", Value: q.limit()}},
+		)
+		cursor, err = m.scores.Aggregate(ctx, pipeline)
+	} else {
+		sort := bson.D{{Key: "pontos", Value: -1}, {Key: "data", Value: 1}}
+		if q.Recent {
+			sort = bson.D{{Key: "data", Value: -1}}
+		}
+		cursor, err = m.scores.Find(ctx,
+			filter,
+			options.Find().
+				SetSort(sort).
+				SetSkip(int64(q.Offset)).
+				SetLimit(int64(q.limit())).
+				SetProjection(bson.M{"replay": 0}), // o replay so interessa na verificacao
+		)
 	}
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	var results []Score
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// o rankable do MongoDB: verificado, sem cheats (sem o campo, nos docs
+// antigos, ou lista vazia) e fora do teste de carga
+var rankableFilter = bson.M{
+	"verificado": true,
+	"cheats":     bson.M{"$in": bson.A{nil, bson.A{}}},
+	"carga":      bson.M{"$ne": true},
+}
+
+// estatisticas do jogador com pipelines: o primeiro resume o historico e
+// pega as ultimas partidas, o segundo conta os jogadores do ranking. A
+// posicao vem do PlayerRank, para a tela de estatisticas e o M do ranking
+// usarem a mesma ordem (empate de pontos: quem fez antes fica na frente)
+func (m *MongoStore) PlayerStats(name, id string) (PlayerStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.ReadTimeout)
 	defer cancel()
 
-	_, err := scoresCollection.InsertOne(ctx, bson.M{
-		"nome":   name,
-		"pontos": points,
-		"data":   time.Now(),
+	st := PlayerStats{Nome: name}
+	player := bson.M{"nome": name}
+	if id != "" {
+		player = bson.M{"jogador_id": id}
+	}
+
+	cursor, err := m.scores.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$and": bson.A{rankableFilter, player}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "data", Value: -1}}}},
+		{{Key: "$facet", Value: bson.M{
+			"resumo": bson.A{bson.M{"$group": bson.M{
+				"_id":          nil,
+				"partidas":     bson.M{"$sum": 1},
+				"melhor":       bson.M{"$max": "$pontos"},
+				"media":        bson.M{"$avg": "$pontos"},
+				"melhor_nivel": bson.M{"$max": "$nivel"},
+				"melhor_combo": bson.M{"$max": "$max_combo"},
+				"tempo_total":  bson.M{"$sum": "$duracao"},
+			}}},
+			"recentes": bson.A{
+				bson.M{"$limit": STATS_TREND},
+				bson.M{"$project": bson.M{"_id": 0, "pontos": 1}},
+			},
+		}}},
 	})
+	if err != nil {
+		return st, err
+	}
+	var summary []struct {
+		Resumo   []PlayerStats `bson:"resumo"`
+		Recentes []struct {
+			Pontos int `bson:"pontos"`
+		} `bson:"recentes"`
+	}
+	if err := cursor.All(ctx, &summary); err != nil {
+		return st, err
+	}
+	if len(summary) == 0 || len(summary[0].Resumo) == 0 {
+		return st, nil
+	}
+	st = summary[0].Resumo[0]
+	st.Nome = name
+	// vieram da mais nova para a mais antiga
+	for i := len(summary[0].Recentes) - 1; i >= 0; i-- {
+		st.Recentes = append(st.Recentes, summary[0].Recentes[i].Pontos)
+	}
 
+	cursor, err = m.scores.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: rankableFilter}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"$ifNull": bson.A{"$jogador_id", "$nome"}}}}},
+		{{Key: "$count", Value: "n"}},
+	})
 	if err != nil {
-		log.Printf("Erro ao salvar score no MongoDB: %v", err)
-	} else {
-		log.Printf("Score salvo com sucesso: %s — %d pontos", name, points)
+		return st, err
+	}
+	var total []struct {
+		N int `bson:"n"`
+	}
+	if err := cursor.All(ctx, &total); err != nil {
+		return st, err
+	}
+	if len(total) > 0 {
+		st.Jogadores = total[0].N
 	}
+
+	st.Posicao, err = m.PlayerRank(ScoreQuery{BestPerPlayer: true}, name, id)
+	return st, err
 }
 
-func GetTop10() []Score {
-	// modo local ou MongoDB indisponível → retorna mock
-	if scoresCollection == nil || !isDocker {
-		return []Score{
-			{Nome: "JOGADOR01", Pontos: 250, Data: time.Now().Add(-time.Hour)},
-			{Nome: "JOGADOR02", Pontos: 180, Data: time.Now().Add(-2 * time.Hour)},
-			{Nome: generateUserID(), Pontos: 120, Data: time.Now()},
-		}
+// posicao do melhor score do jogador: conta quem fica na frente dele na
+// mesma ordem do Top (mais pontos, ou os mesmos pontos feitos antes)
+func (m *MongoStore) PlayerRank(q ScoreQuery, name, id string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.ReadTimeout)
+	defer cancel()
+
+	filter := scoreFilter(q)
+	player := bson.M{"nome": name}
+	if id != "" {
+		player = bson.M{"jogador_id": id}
+	}
+	var best Score
+	err := m.scores.FindOne(ctx,
+		bson.M{"$and": bson.A{filter, player}},
+		options.FindOne().
+			SetSort(bson.D{{Key: "pontos", Value: -1}, {Key: "data", Value: 1}}).
+			SetProjection(bson.M{"pontos": 1, "data": 1}),
+	).Decode(&best)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ahead := bson.M{"$or": bson.A{
+		bson.M{"pontos": bson.M{"$gt": best.Pontos}},
+		bson.M{"pontos": best.Pontos, "data": bson.M{"$lt": best.Data}},
+	}}
+	if !q.BestPerPlayer {
+		n, err := m.scores.CountDocuments(ctx, bson.M{"$and": bson.A{filter, ahead}})
+		return int(n) + 1, err
+	}
 
-	cursor, err := scoresCollection.Find(ctx,
-		bson.M{},
-		options.Find().SetSort(bson.D{{Key: "pontos", Value: -1}}).SetLimit(10),
+	pipeline := append(bestPerPlayerStages(filter),
+		bson.D{{Key: "$match", Value: ahead}},
+		bson.D{{Key: "$count", Value: "n"}},
 	)
+	cursor, err := m.scores.Aggregate(ctx, pipeline)
 	if err != nil {
-		log.Printf("Erro ao buscar ranking: %v", err)
-		return nil
+		return 0, err
 	}
-	defer cursor.Close(ctx)
+	var count []struct {
+		N int `bson:"n"`
+	}
+	if err := cursor.All(ctx, &count); err != nil {
+		return 0, err
+	}
+	if len(count) == 0 {
+		return 1, nil
+	}
+	return count[0].N + 1, nil
+}
 
-	var results []Score
-	if err := cursor.All(ctx, &results); err != nil {
-		log.Printf("Erro ao decodificar scores: %v", err)
-		return nil
+// cria ou atualiza o perfil em players (o _id e o UUID do perfil)
+func (m *MongoStore) SaveProfile(ctx context.Context, p Profile) error {
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.WriteTimeout)
+	defer cancel()
+
+	_, err := m.players.UpdateByID(ctx, p.ID, bson.M{
+		"$set":         bson.M{"nome": p.Nome, "ultimo_uso": p.UltimoUso},
+		"$setOnInsert": bson.M{"criado": p.Criado},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+// change stream das insercoes em snake_scores. So funciona em replica set;
+// num servidor avulso o Watch falha e o ranking usa polling
+func (m *MongoStore) WatchScores(ctx context.Context) (<-chan Score, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "operationType", Value: "insert"}}}},
+		{{Key: "$project", Value: bson.D{{Key: "fullDocument.replay", Value: 0}}}},
+	}
+	stream, err := m.scores.Watch(ctx, pipeline)
+	if err != nil {
+		return nil, err
 	}
 
-	return results
+	out := make(chan Score, 16)
+	go func() {
+		defer close(out)
+		defer stream.Close(context.Background())
+		for stream.Next(ctx) {
+			var change struct {
+				FullDocument Score `bson:"fullDocument"`
+			}
+			if err := stream.Decode(&change); err != nil {
+				log.Printf("Erro ao ler change stream: %v", err)
+				continue
+			}
+			select {
+			case out <- change.FullDocument:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			log.Printf("Change stream de snake_scores caiu: %v", err)
+		}
+	}()
+	return out, nil
+}
+
+func (m *MongoStore) Close() error {
+	return m.client.Disconnect(context.Background())
 }
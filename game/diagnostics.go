@@ -0,0 +1,108 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// uma linha da tela de diagnostico
+type DiagnosticItem struct {
+	Label string
+	Value string
+}
+
+// DiagnosticsStore e opcional: stores que sabem descrever a propria
+// conexao (config em uso, estado do replica set, fila local)
+type DiagnosticsStore interface {
+	Diagnostics(ctx context.Context) []DiagnosticItem
+}
+
+// Diagnostics do store, se ele tiver
+func storeDiagnostics(ctx context.Context, store ScoreStore) []DiagnosticItem {
+	if d, ok := store.(DiagnosticsStore); ok {
+		return d.Diagnostics(ctx)
+	}
+	return []DiagnosticItem{{"Store", fmt.Sprintf("%T", store)}}
+}
+
+func (m *MemoryStore) Diagnostics(ctx context.Context) []DiagnosticItem {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return []DiagnosticItem{
+		{"Store", "memoria (scores so desta sessao)"},
+		{"Scores", fmt.Sprint(len(m.scores))},
+	}
+}
+
+func (f *FileStore) Diagnostics(ctx context.Context) []DiagnosticItem {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return []DiagnosticItem{
+		{"Store", "arquivo local"},
+		{"Arquivo", f.path},
+		{"Scores", fmt.Sprint(len(f.scores))},
+	}
+}
+
+func (v *VerifyingStore) Diagnostics(ctx context.Context) []DiagnosticItem {
+	return storeDiagnostics(ctx, v.ScoreStore)
+}
+
+func (q *QueuedStore) Diagnostics(ctx context.Context) []DiagnosticItem {
+	items := storeDiagnostics(ctx, q.remote)
+	return append(items, DiagnosticItem{"Fila local", fmt.Sprintf("%d scores aguardando envio", q.Pending())})
+}
+
+// config em uso (e de onde veio cada valor) e o que o replica set responde
+func (m *MongoStore) Diagnostics(ctx context.Context) []DiagnosticItem {
+	items := []DiagnosticItem{{"Store", "MongoDB"}}
+	for _, s := range mongoSettings {
+		value := s.get(&m.cfg)
+		if s.key == "uri" {
+			value = m.cfg.safeURI()
+		}
+		source := m.cfg.Source[s.key]
+		if source == "" {
+			source = "padrao"
+		}
+		items = append(items, DiagnosticItem{s.key, value + "  (" + source + ")"})
+	}
+
+	start := time.Now()
+	if err := m.client.Ping(ctx, nil); err != nil {
+		return append(items, DiagnosticItem{"Ping", "falhou: " + err.Error()})
+	}
+	items = append(items, DiagnosticItem{"Ping", time.Since(start).Round(time.Millisecond).String()})
+
+	var hello struct {
+		SetName string   `bson:"setName"`
+		Primary string   `bson:"primary"`
+		Me      string   `bson:"me"`
+		Hosts   []string `bson:"hosts"`
+	}
+	if err := m.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return append(items, DiagnosticItem{"Replica set", "erro: " + err.Error()})
+	}
+	if hello.SetName == "" {
+		items = append(items, DiagnosticItem{"Replica set", "nenhum (servidor avulso)"})
+	} else {
+		items = append(items,
+			DiagnosticItem{"Replica set", fmt.Sprintf("%s, %d membros", hello.SetName, len(hello.Hosts))},
+			DiagnosticItem{"Primario", hello.Primary},
+			DiagnosticItem{"Respondeu", hello.Me},
+		)
+	}
+
+	// sem migracao registrada o Decode falha e fica a versao 0
+	var last appliedMigration
+	m.db.Collection("schema_migrations").FindOne(ctx,
+		bson.M{"_id": bson.M{"$type": "number"}},
+		options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}}),
+	).Decode(&last)
+	items = append(items, DiagnosticItem{"Schema", fmt.Sprintf("versao %d de %d", last.Versao, len(migrations))})
+	return items
+}
@@ -0,0 +1,62 @@
+package game
+
+import (
+	"context"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// config do banco e estado da conexao; R consulta de novo
+func (g *Game) showDiagnostics() {
+	for {
+		g.drawDiagnostics(nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		items := storeDiagnostics(ctx, g.store)
+		cancel()
+		g.drawDiagnostics(items)
+
+		for {
+			ev := <-g.events
+			if ev.Type != termbox.EventKey {
+				continue
+			}
+			if ev.Key == termbox.KeyEsc {
+				return
+			}
+			if ev.Ch == 'r' || ev.Ch == 'R' {
+				break
+			}
+		}
+	}
+}
+
+func (g *Game) drawDiagnostics(items []DiagnosticItem) {
+	g.r.Clear()
+	width, height := g.r.Size()
+
+	title := "DIAGNOSTICO"
+	g.drawText((width-len(title))/2, 1, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault, title)
+
+	if items == nil {
+		msg := "Consultando o banco..."
+		g.drawText((width-len(msg))/2, height/2, termbox.ColorWhite, termbox.ColorDefault, msg)
+	}
+	x := 4
+	for i, item := range items {
+		y := 3 + i
+		if y >= height-3 {
+			break
+		}
+		g.drawText(x, y, termbox.ColorCyan, termbox.ColorDefault, item.Label)
+		value := []rune(item.Value)
+		if len(value) > width-x-28 {
+			value = value[:max(width-x-28, 0)]
+		}
+		g.drawText(x+26, y, termbox.ColorWhite, termbox.ColorDefault, string(value))
+	}
+
+	backMsg := "R atualiza • ESC volta ao menu"
+	g.drawText((width-len([]rune(backMsg)))/2, height-2, termbox.ColorGreen, termbox.ColorDefault, backMsg)
+	g.r.Flush()
+}
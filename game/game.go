@@ -2,87 +2,207 @@ package game
 
 import (
 	"fmt"
-	"sync"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/nsf/termbox-go"
 )
 
 type Game struct {
-	arena       *Arena
-	isRunning   bool
-	score       int
-	userID      string
-	bonusActive bool
-	bonusType   string
-	bonusTimer  *time.Timer
-	speed       time.Duration
-	menuSnake   []Coord
-	menuDir     Coord
-	menuTicker  *time.Ticker
-	menuMutex   sync.Mutex
-	stopChan    chan bool
+	r             Renderer
+	store         ScoreStore
+	events        chan termbox.Event
+	sim           *Simulation
+	arena         *Arena
+	pendingInputs []Input
+	isRunning     bool
+	score         int
+	userID        string
+	profileID     string       // UUID do perfil local, vazio sem perfil
+	profiles      *ProfileBook // nil quando o nome vem de fora (SSH, -name)
+	p2Name        string       // jogador 2 do mesmo teclado
+	p2ProfileID   string       // perfil local do jogador 2, se o nome for de um
+	speed         time.Duration
+	lastReplay    *Replay
+	devMode       bool
+	status        string
+	menuSnake     []Coord
+	menuDir       Coord
+	mode          string     // MODE_SOLO, MODE_VERSUS ou MODE_COOP
+	autopilot     *Autopilot // so na demonstracao
+	demoQuit      bool
+	roundQuit     bool // a partida acabou no ESC, nao com uma morte
+	startInDemo   bool
+	spectateAddr  string
+	spectate      *spectatorHub // espectadores do jogo local, nil sem -spectate
+	spectators    int           // quantos estao assistindo, mostrado no HUD
+	watching      bool          // esta tela so assiste, nao joga
+	stopped       chan struct{} // fecha quando Start retorna
 }
 
-func NewGame() *Game {
-	initDB()
-	return &Game{
-		arena:     newArena(60, 25),
-		userID:    generateUserID(),
-		speed:     120 * time.Millisecond,
-		menuSnake: []Coord{{X: 5, Y: 5}, {X: 4, Y: 5}, {X: 3, Y: 5}},
-		menuDir:   Coord{X: 1, Y: 0},
-		stopChan:  make(chan bool),
+// dependencias do jogo, campos vazios usam o padrao
+type Options struct {
+	Renderer Renderer
+	Store    ScoreStore
+	DevMode  bool   // libera os cheats g/p/l/b/k e o ranking dev
+	Demo     bool   // abre direto na demonstracao com o autopilot
+	Name     string // identidade do jogador, vazio usa o perfil local
+	Spectate string // endereco para espectadores assistirem as partidas locais
+}
+
+// sem Store abre um com OpenScoreStore; o erro e o dele, com a config do
+// MongoDB invalida, antes de qualquer coisa aparecer no terminal
+func NewGame(opts Options) (*Game, error) {
+	if opts.Renderer == nil {
+		opts.Renderer = NewTermboxRenderer()
+	}
+	if opts.Store == nil {
+		store, err := OpenScoreStore()
+		if err != nil {
+			return nil, err
+		}
+		opts.Store = store
 	}
+	// sem nome explicito o jogador e o perfil desta maquina; sem perfil
+	// ainda, Start pede o nome antes do menu
+	var profiles *ProfileBook
+	if opts.Name == "" {
+		book, err := LoadProfiles(profilesPath())
+		if err != nil {
+			log.Printf("Erro ao ler perfis: %v", err)
+		}
+		profiles = book
+		if p := book.Current(); p != nil {
+			opts.Name = p.Nome
+		}
+	}
+	sim := NewSimulation(60, 25, time.Now().UnixNano())
+	return &Game{
+		r:            opts.Renderer,
+		store:        opts.Store,
+		devMode:      opts.DevMode,
+		startInDemo:  opts.Demo,
+		spectateAddr: opts.Spectate,
+		mode:         MODE_SOLO,
+		events:       make(chan termbox.Event),
+		stopped:      make(chan struct{}),
+		sim:          sim,
+		arena:        sim.Arena,
+		userID:       opts.Name,
+		profiles:     profiles,
+		speed:        TICK_RATE,
+		menuSnake:    []Coord{{X: 5, Y: 5}, {X: 4, Y: 5}, {X: 3, Y: 5}},
+		menuDir:      Coord{X: 1, Y: 0},
+	}, nil
 }
 
 func (g *Game) Start() {
-	err := termbox.Init()
+	err := g.r.Init()
 	if err != nil {
 		panic(err)
 	}
-	defer termbox.Close()
-	defer g.cleanup()
-
-	termbox.SetInputMode(termbox.InputEsc)
-	termbox.HideCursor()
+	defer g.r.Close()
+	defer close(g.stopped)
+	g.pumpEvents()
+
+	if g.spectateAddr != "" {
+		g.spectate = newSpectatorHub()
+		ln, err := listenSpectators(g.spectateAddr, g.spectate)
+		if err != nil {
+			log.Printf("Erro ao abrir espectadores em %s: %v", g.spectateAddr, err)
+			g.spectate = nil
+		} else {
+			defer ln.Close()
+		}
+	}
 
+	if g.startInDemo {
+		g.startDemo()
+	}
+	if !g.ensureProfile() {
+		return
+	}
 	g.showMainMenu()
 }
 
-func (g *Game) cleanup() {
-	if g.menuTicker != nil {
-		g.menuTicker.Stop()
+// joga direto no servidor em addr, sem passar pelo menu
+func (g *Game) StartOnline(addr string) error {
+	if err := g.r.Init(); err != nil {
+		return err
 	}
-	if g.bonusTimer != nil {
-		g.bonusTimer.Stop()
+	defer g.r.Close()
+	defer close(g.stopped)
+	g.pumpEvents()
+
+	if !g.ensureProfile() {
+		return nil
 	}
-	close(g.stopChan)
+	return g.playOnline(addr)
 }
 
-func (g *Game) showMainMenu() {
-	selected := 0
-	options := []string{"Iniciar Jogo", "Ver Ranking", "Sair"}
+// so assiste a partida em addr (servidor ou jogo local com -spectate)
+func (g *Game) StartWatching(addr string) error {
+	if err := g.r.Init(); err != nil {
+		return err
+	}
+	defer g.r.Close()
+	defer close(g.stopped)
+	g.pumpEvents()
 
-	g.menuTicker = time.NewTicker(100 * time.Millisecond)
-	defer g.menuTicker.Stop()
+	// quem so assiste nao precisa de perfil
+	if g.userID == "" {
+		g.userID = generateUserID()
+	}
+	return g.watchOnline(addr)
+}
 
+// uma unica goroutine le o teclado, todas as telas consomem g.events.
+// Se o terminal sumir (sessao SSH fechada) as telas recebem ESC ate o
+// jogo voltar do menu, assim Start retorna sem ninguem apertar nada
+func (g *Game) pumpEvents() {
 	go func() {
+		for {
+			ev := g.r.PollEvent()
+			if ev.Type == termbox.EventInterrupt || ev.Type == termbox.EventError {
+				break
+			}
+			g.events <- ev
+		}
 		for {
 			select {
-			case <-g.menuTicker.C:
-				g.animateMenuSnake()
-			case <-g.stopChan:
+			case g.events <- termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEsc}:
+			case <-g.stopped:
 				return
 			}
 		}
 	}()
+}
+
+func (g *Game) showMainMenu() {
+	selected := 0
+	options := []string{"Iniciar Jogo", "Dois Jogadores", "Demonstracao", "Assistir Replay", "Ver Ranking", "Estatisticas", "Diagnostico"}
+	if g.profiles != nil {
+		// sessoes SSH e -name ja chegam com o nome
+		options = append(options, "Perfil")
+	}
+	options = append(options, "Sair")
+
+	menuTicker := time.NewTicker(100 * time.Millisecond)
+	defer menuTicker.Stop()
 
 	for {
 		g.drawMainMenu(selected, options)
-		termbox.Flush()
+		g.r.Flush()
+
+		var ev termbox.Event
+		select {
+		case <-menuTicker.C:
+			g.animateMenuSnake()
+			continue
+		case ev = <-g.events:
+		}
 
-		ev := termbox.PollEvent()
 		switch ev.Type {
 		case termbox.EventKey:
 			switch ev.Key {
@@ -91,14 +211,24 @@ func (g *Game) showMainMenu() {
 			case termbox.KeyArrowDown:
 				selected = (selected + 1) % len(options)
 			case termbox.KeyEnter:
-				switch selected {
-				case 0:
+				switch options[selected] {
+				case "Iniciar Jogo":
 					g.startGame()
-					return
-				case 1:
+				case "Dois Jogadores":
+					g.showTwoPlayerMenu()
+				case "Demonstracao":
+					g.startDemo()
+				case "Assistir Replay":
+					g.showReplays()
+				case "Ver Ranking":
 					g.showLeaderboard()
-					return
-				case 2:
+				case "Estatisticas":
+					g.showStats()
+				case "Diagnostico":
+					g.showDiagnostics()
+				case "Perfil":
+					g.showProfiles()
+				case "Sair":
 					return
 				}
 			case termbox.KeyEsc:
@@ -109,7 +239,7 @@ func (g *Game) showMainMenu() {
 }
 
 func (g *Game) animateMenuSnake() {
-	width, height := termbox.Size()
+	width, height := g.r.Size()
 	head := g.menuSnake[0]
 
 	newHead := Coord{X: head.X + g.menuDir.X, Y: head.Y + g.menuDir.Y}
@@ -128,7 +258,7 @@ func (g *Game) animateMenuSnake() {
 		menuLeft := (width - 20) / 2
 		menuRight := menuLeft + 20
 		menuTop := height/2 - 2
-		menuBottom := height/2 + 4
+		menuBottom := height/2 + 16
 
 		if newHead.X >= menuLeft && newHead.X <= menuRight &&
 			newHead.Y >= menuTop && newHead.Y <= menuBottom {
@@ -151,8 +281,8 @@ func (g *Game) animateMenuSnake() {
 }
 
 func (g *Game) drawMainMenu(selected int, options []string) {
-	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
-	width, height := termbox.Size()
+	g.r.Clear()
+	width, height := g.r.Size()
 
 	// desenhar cobrinha animada no fundo
 	for i, seg := range g.menuSnake {
@@ -160,198 +290,199 @@ func (g *Game) drawMainMenu(selected int, options []string) {
 		if i == 0 {
 			color = termbox.ColorGreen | termbox.AttrBold
 		}
-		termbox.SetCell(seg.X, seg.Y, '█', color, termbox.ColorDefault)
+		g.r.SetCell(seg.X, seg.Y, '█', color, termbox.ColorDefault)
 	}
 
 	// game title
 	title := "SNAKE GO - UFPI 2025"
 	subtitle := "Ally,Vini, Kleber Versao.0.7"
-	drawText((width-len(title))/2, height/2-5, termbox.ColorGreen|termbox.AttrBold, termbox.ColorDefault, title)
-	drawText((width-len(subtitle))/2, height/2-4, termbox.ColorCyan, termbox.ColorDefault, subtitle)
+	g.drawText((width-len(title))/2, height/2-5, termbox.ColorGreen|termbox.AttrBold, termbox.ColorDefault, title)
+	g.drawText((width-len(subtitle))/2, height/2-4, termbox.ColorCyan, termbox.ColorDefault, subtitle)
 
-	// op
+	// op, sem linha em branco entre elas se o terminal for baixo
+	spacing := 2
+	if height/2-1+len(options)*2 > height-3 {
+		spacing = 1
+	}
 	for i, option := range options {
 		x := (width - 15) / 2
-		y := height/2 - 1 + i*2
+		y := height/2 - 1 + i*spacing
 
 		fgColor := termbox.ColorWhite
 		if i == selected {
 			fgColor = termbox.ColorYellow | termbox.AttrBold
-			drawText(x-2, y, fgColor, termbox.ColorDefault, ">")
+			g.drawText(x-2, y, fgColor, termbox.ColorDefault, ">")
 		}
 
-		drawText(x, y, fgColor, termbox.ColorDefault, option)
+		g.drawText(x, y, fgColor, termbox.ColorDefault, option)
 	}
 
 	userInfo := fmt.Sprintf("Jogador: %s", g.userID)
-	drawText(2, height-1, termbox.ColorBlue, termbox.ColorDefault, userInfo)
+	g.drawText(2, height-1, termbox.ColorBlue, termbox.ColorDefault, userInfo)
 
 	controls := "Use ↑↓ para navegar, ENTER para selecionar, ESC para sair"
-	drawText((width-len(controls))/2, height-2, termbox.ColorDarkGray, termbox.ColorDefault, controls)
+	g.drawText((width-len(controls))/2, height-2, termbox.ColorDarkGray, termbox.ColorDefault, controls)
 }
 
-func (g *Game) showLeaderboard() {
-	inLeaderboard := true
-
-	for inLeaderboard {
-		termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
-
-		width, height := termbox.Size()
-		title := "RANKING - TOP 10"
-		drawText((width-len(title))/2, 2, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault, title)
-
-		scores := GetTop10()
-		if len(scores) == 0 {
-			noScores := "Nenhum score registrado ainda!"
-			drawText((width-len(noScores))/2, height/2, termbox.ColorWhite, termbox.ColorDefault, noScores)
-		} else {
-			// cabeçalho
-			header := "Pos Jogador       Pontos Data"
-			drawText((width-len(header))/2, 5, termbox.ColorCyan|termbox.AttrBold, termbox.ColorDefault, header)
-
-			// separador
-			separator := "-----------------------------"
-			drawText((width-len(separator))/2, 6, termbox.ColorWhite, termbox.ColorDefault, separator)
-
-			// pontuacoes
-			for i, score := range scores {
-				if i >= 10 {
-					break
-				}
-
-				color := termbox.ColorWhite
-				if i == 0 {
-					color = termbox.ColorYellow | termbox.AttrBold
-				} else if i == 1 {
-					color = termbox.ColorWhite | termbox.AttrBold
-				} else if i == 2 {
-					color = termbox.ColorMagenta | termbox.AttrBold
-				}
-
-				playerDisplay := score.Nome
-				if len(playerDisplay) > 12 {
-					playerDisplay = playerDisplay[:12]
-				}
-
-				line := fmt.Sprintf("%2d. %-12s %6d %s",
-					i+1, playerDisplay, score.Pontos, score.Data.Format("02/01"))
-
-				drawText((width-len(line))/2, 7+i, color, termbox.ColorDefault, line)
-			}
-		}
-
-		backMsg := "Pressione ESC para voltar ao menu"
-		drawText((width-len(backMsg))/2, height-3, termbox.ColorGreen, termbox.ColorDefault, backMsg)
-
-		termbox.Flush()
-
-		ev := termbox.PollEvent()
-		if ev.Type == termbox.EventKey && ev.Key == termbox.KeyEsc {
-			inLeaderboard = false
-		}
+// joga partidas seguidas ate o jogador voltar ao menu
+func (g *Game) startGame() {
+	for g.playRound() {
 	}
+}
 
-	g.showMainMenu()
+// o autopilot joga partidas seguidas ate alguem apertar uma tecla.
+// Partidas de demonstracao nao vao para o ranking
+func (g *Game) startDemo() {
+	g.autopilot = NewAutopilot()
+	g.demoQuit = false
+	defer func() { g.autopilot = nil }()
+	for g.playRound() {
+	}
 }
 
-func (g *Game) startGame() {
+func (g *Game) playRound() bool {
 	g.isRunning = true
+	g.roundQuit = false
 	g.score = 0
-	g.bonusActive = false
-	g.bonusType = ""
-	g.speed = 120 * time.Millisecond
-	g.arena = newArena(60, 25)
+	g.speed = TICK_RATE
+	players := 1
+	if g.mode != MODE_SOLO {
+		players = 2
+	}
+	g.sim = NewMatchSimulation(60, 25, time.Now().UnixNano(), g.mode, players)
+	g.arena = g.sim.Arena
+	g.pendingInputs = g.pendingInputs[:0]
 
 	ticker := time.NewTicker(g.speed)
 	defer ticker.Stop()
 
-	eventQueue := make(chan termbox.Event)
-	go func() {
-		for g.isRunning {
-			eventQueue <- termbox.PollEvent()
-		}
-	}()
-
 	for g.isRunning {
 		select {
-		case ev := <-eventQueue:
+		case ev := <-g.events:
 			if ev.Type == termbox.EventKey {
 				g.handleInput(ev)
 			}
 		case <-ticker.C:
+			if g.autopilot != nil {
+				g.pendingInputs = append(g.pendingInputs, g.autopilot.Next(g.arena)...)
+			}
 			g.update()
+			g.broadcastSpectators()
 			g.drawGame()
 		}
 	}
 
-	g.gameOver()
+	if g.spectate != nil {
+		g.spectate.over(g.roundResults())
+	}
+
+	if g.autopilot != nil {
+		return g.demoOver()
+	}
+	if len(g.arena.Players) > 1 {
+		return g.matchOver()
+	}
+	return g.gameOver()
+}
+
+// fim de partida da demonstracao: mostra o resultado e recomeca sozinho
+func (g *Game) demoOver() bool {
+	if g.demoQuit {
+		return false
+	}
+
+	width, height := g.r.Size()
+	text := fmt.Sprintf("GAME OVER - Score %d - Nivel %d", g.score, g.arena.Level)
+	g.drawText((width-len(text))/2, height/2, termbox.ColorRed|termbox.AttrBold, termbox.ColorDefault, text)
+	g.r.Flush()
+
+	select {
+	case <-time.After(3 * time.Second):
+		return true
+	case ev := <-g.events:
+		return ev.Type != termbox.EventKey
+	}
 }
 
+// traduz teclas em entradas da simulacao, aplicadas no proximo tick
 func (g *Game) handleInput(ev termbox.Event) {
-	// cheats: suposto a bugs
-	if ev.Type == termbox.EventKey {
+	// na demonstracao quem joga e o autopilot, qualquer tecla volta ao menu
+	if g.autopilot != nil {
+		g.demoQuit = true
+		g.isRunning = false
+		return
+	}
+
+	// cheats: suposto a bugs, so no modo desenvolvedor
+	if ev.Type == termbox.EventKey && g.devMode {
 		switch ev.Ch {
 		case 'g', 'G': // god mode
-			g.arena.Snake.Body = append(g.arena.Snake.Body, g.arena.Snake.Body[len(g.arena.Snake.Body)-1])
-			g.arena.Snake.Body = append(g.arena.Snake.Body, g.arena.Snake.Body[len(g.arena.Snake.Body)-1])
-			g.arena.AddMessage("god mode, isso e uma maldicao", 3*time.Second)
+			g.pendingInputs = append(g.pendingInputs, INPUT_CHEAT_GROW)
 		case 'p', 'P': // +1000 pontos instantaneos
-			g.arena.Points += 1000
-			g.arena.AddMessage("adm desligado, voce recebeu +1000 pts", 3*time.Second)
+			g.pendingInputs = append(g.pendingInputs, INPUT_CHEAT_POINTS)
 		case 'l', 'L': // subir de nível
-			g.arena.Level += 5
-			g.arena.increaseDifficulty()
-			g.arena.AddMessage("voce recebeu uma dadiva! level +5", 3*time.Second)
+			g.pendingInputs = append(g.pendingInputs, INPUT_CHEAT_LEVEL)
 		case 'b', 'B': // spawn boss instantâneo
-			boss := newBoss(g.arena.Width, g.arena.Height, g.arena.Snake)
-			g.arena.Bosses = append(g.arena.Bosses, boss)
-			g.arena.AddMessage("um bug foi encontrado, um estrangeiro apareceu", 4*time.Second)
+			g.pendingInputs = append(g.pendingInputs, INPUT_CHEAT_BOSS)
 		case 'k', 'K': // matar todos os bosses
-			for _, boss := range g.arena.Bosses {
-				boss.IsAlive = false
-			}
-			g.arena.AddMessage("uma bencao divina extinguiu os estrangeiros", 4*time.Second)
+			g.pendingInputs = append(g.pendingInputs, INPUT_CHEAT_KILL)
+		}
+	}
+
+	// segundo jogador no WASD
+	if len(g.arena.Players) > 1 {
+		switch ev.Ch {
+		case 'w', 'W':
+			g.pendingInputs = append(g.pendingInputs, INPUT_P2_UP)
+		case 's', 'S':
+			g.pendingInputs = append(g.pendingInputs, INPUT_P2_DOWN)
+		case 'a', 'A':
+			g.pendingInputs = append(g.pendingInputs, INPUT_P2_LEFT)
+		case 'd', 'D':
+			g.pendingInputs = append(g.pendingInputs, INPUT_P2_RIGHT)
 		}
 	}
 
 	// mapeamento das teclas
 	switch ev.Key {
 	case termbox.KeyArrowUp:
-		g.arena.Snake.ChangeDir(0, -1)
+		g.pendingInputs = append(g.pendingInputs, INPUT_UP)
 	case termbox.KeyArrowDown:
-		g.arena.Snake.ChangeDir(0, 1)
+		g.pendingInputs = append(g.pendingInputs, INPUT_DOWN)
 	case termbox.KeyArrowLeft:
-		g.arena.Snake.ChangeDir(-1, 0)
+		g.pendingInputs = append(g.pendingInputs, INPUT_LEFT)
 	case termbox.KeyArrowRight:
-		g.arena.Snake.ChangeDir(1, 0)
+		g.pendingInputs = append(g.pendingInputs, INPUT_RIGHT)
 	case termbox.KeyEsc:
+		g.roundQuit = true
 		g.isRunning = false
 	}
 }
 
 func (g *Game) update() {
-	if !g.arena.Tick(g) {
+	if !g.sim.Step(g.pendingInputs...) {
 		g.isRunning = false
 	}
+	g.pendingInputs = g.pendingInputs[:0]
 	g.score = g.arena.Points
 }
 
 func (g *Game) drawMessages() {
-	now := time.Now()
-	width, _ := termbox.Size()
+	now := g.arena.Now()
+	width, _ := g.r.Size()
 
 	for i := len(g.arena.Messages) - 1; i >= 0; i-- {
 		msg := g.arena.Messages[i]
 		if now.Sub(msg.CreatedAt) < msg.Duration {
 			x := (width - len(msg.Text)) / 2
 			y := 2 + (len(g.arena.Messages)-1-i)*2
-			drawText(x, y, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault, msg.Text)
+			g.drawText(x, y, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault, msg.Text)
 		}
 	}
 }
 
 func (g *Game) drawGame() {
-	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+	g.r.Clear()
 
 	// desenhar borda da arena
 	g.drawArenaBorder()
@@ -368,43 +499,35 @@ func (g *Game) drawGame() {
 	// desenhar comida
 	g.drawFood()
 
+	// desenhar power-ups
+	g.drawPowerUps()
+
 	// desenhar mensagens
 	g.drawMessages()
 
 	// desenhar HUD expandido
 	g.drawHUD()
 
-	termbox.Flush()
+	g.r.Flush()
 }
 
 func (g *Game) drawArenaBorder() {
 	// cantos
-	termbox.SetCell(g.arena.X-1, g.arena.Y-1, '┌', termbox.ColorWhite, termbox.ColorDefault)
-	termbox.SetCell(g.arena.X+g.arena.Width, g.arena.Y-1, '┐', termbox.ColorWhite, termbox.ColorDefault)
-	termbox.SetCell(g.arena.X-1, g.arena.Y+g.arena.Height, '└', termbox.ColorWhite, termbox.ColorDefault)
-	termbox.SetCell(g.arena.X+g.arena.Width, g.arena.Y+g.arena.Height, '┘', termbox.ColorWhite, termbox.ColorDefault)
+	g.r.SetCell(g.arena.X-1, g.arena.Y-1, '┌', termbox.ColorWhite, termbox.ColorDefault)
+	g.r.SetCell(g.arena.X+g.arena.Width, g.arena.Y-1, '┐', termbox.ColorWhite, termbox.ColorDefault)
+	g.r.SetCell(g.arena.X-1, g.arena.Y+g.arena.Height, '└', termbox.ColorWhite, termbox.ColorDefault)
+	g.r.SetCell(g.arena.X+g.arena.Width, g.arena.Y+g.arena.Height, '┘', termbox.ColorWhite, termbox.ColorDefault)
 
 	// bordas horizontais
 	for x := g.arena.X; x < g.arena.X+g.arena.Width; x++ {
-		termbox.SetCell(x, g.arena.Y-1, '─', termbox.ColorWhite, termbox.ColorDefault)
-		termbox.SetCell(x, g.arena.Y+g.arena.Height, '─', termbox.ColorWhite, termbox.ColorDefault)
+		g.r.SetCell(x, g.arena.Y-1, '─', termbox.ColorWhite, termbox.ColorDefault)
+		g.r.SetCell(x, g.arena.Y+g.arena.Height, '─', termbox.ColorWhite, termbox.ColorDefault)
 	}
 
 	// bordas verticais
 	for y := g.arena.Y; y < g.arena.Y+g.arena.Height; y++ {
-		termbox.SetCell(g.arena.X-1, y, '│', termbox.ColorWhite, termbox.ColorDefault)
-		termbox.SetCell(g.arena.X+g.arena.Width, y, '│', termbox.ColorWhite, termbox.ColorDefault)
-	}
-}
-
-func (g *Game) drawObstacles() {
-	for _, obs := range g.arena.Obstacles {
-		char := '█'
-		color := termbox.ColorMagenta
-		if obs.IsTemporary {
-			color = termbox.ColorMagenta | termbox.AttrBold
-		}
-		termbox.SetCell(obs.X, obs.Y, char, color, termbox.ColorDefault)
+		g.r.SetCell(g.arena.X-1, y, '│', termbox.ColorWhite, termbox.ColorDefault)
+		g.r.SetCell(g.arena.X+g.arena.Width, y, '│', termbox.ColorWhite, termbox.ColorDefault)
 	}
 }
 
@@ -413,13 +536,24 @@ func (g *Game) drawBosses() {
 		if !boss.IsAlive {
 			continue
 		}
+		blink := boss.IsInvulnerable(g.arena.Now()) && (time.Now().UnixNano()/100000000)%2 == 0
+		species := boss.Species()
 		for i, seg := range boss.Body {
-			color := termbox.ColorRed
-			if i == 0 {
-				color = termbox.ColorRed | termbox.AttrBold
+			color := species.Color
+			if boss.IsMinion {
+				color = termbox.ColorLightRed
 			}
-			char := '■'
-			termbox.SetCell(seg.X, seg.Y, char, color, termbox.ColorDefault)
+			if i == 0 || boss.Phase == 3 {
+				color = color | termbox.AttrBold
+			}
+			if blink {
+				color = termbox.ColorWhite
+			}
+			char := species.Glyph
+			if boss.IsMinion {
+				char = '■'
+			}
+			g.r.SetCell(seg.X, seg.Y, char, color, termbox.ColorDefault)
 		}
 	}
 }
@@ -433,24 +567,42 @@ func (g *Game) drawSnake() {
 		termbox.ColorBlue,
 		termbox.ColorMagenta,
 	}
+	for n, p := range g.arena.Players {
+		base := playerColors[n%len(playerColors)]
+		ghost := g.arena.Now().Before(p.GhostUntil)
+
+		for i, seg := range p.Snake.Body {
+			var color termbox.Attribute
+
+			if !p.Alive {
+				// cobra derrubada fica apagada ate o fim da partida
+				color = termbox.ColorDarkGray
+			} else if p.BonusActive {
+				// rainbow se bônus ativo
+				colorIdx := (i + int(time.Now().UnixNano()/100000000)) % len(rainbowColors)
+				color = rainbowColors[colorIdx] | termbox.AttrBold
+			} else if ghost {
+				// fantasma: cobra apagada
+				color = termbox.ColorDarkGray
+				if i == 0 {
+					color = termbox.ColorWhite
+				}
+			} else {
+				// color tradicional
+				color = base
+				if i == 0 {
+					color = base | termbox.AttrBold
+				}
+			}
 
-	for i, seg := range g.arena.Snake.Body {
-		var color termbox.Attribute
-
-		if g.bonusActive {
-			// rainbow se bônus ativo
-			colorIdx := (i + int(time.Now().UnixNano()/100000000)) % len(rainbowColors)
-			color = rainbowColors[colorIdx] | termbox.AttrBold
-		} else {
-			// color tradicional - green
-			color = termbox.ColorGreen
-			if i == 0 {
-				color = termbox.ColorGreen | termbox.AttrBold
+			// escudo: cabeca ciano
+			if i == 0 && p.Shield && p.Alive {
+				color = termbox.ColorCyan | termbox.AttrBold
 			}
-		}
 
-		char := '■'
-		termbox.SetCell(seg.X, seg.Y, char, color, termbox.ColorDefault)
+			char := '■'
+			g.r.SetCell(seg.X, seg.Y, char, color, termbox.ColorDefault)
+		}
 	}
 }
 
@@ -478,111 +630,144 @@ func (g *Game) drawFood() {
 		}
 
 		// efetuar transparência baseada no tempo restante
-		timeLeft := food.Lifetime - time.Since(food.SpawnTime)
+		timeLeft := food.Lifetime - g.arena.Now().Sub(food.SpawnTime)
 		if timeLeft < 2*time.Second {
 			if (time.Now().UnixNano()/500000000)%2 == 0 {
 				color = color | termbox.AttrBlink
 			}
 		}
 
-		termbox.SetCell(food.X, food.Y, char, color, termbox.ColorDefault)
+		g.r.SetCell(food.X, food.Y, char, color, termbox.ColorDefault)
 	}
 }
 
 func (g *Game) drawHUD() {
-	scoreText := fmt.Sprintf("Score: %d", g.score)
-	drawText(g.arena.X+2, g.arena.Y-3, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault, scoreText)
+	if len(g.arena.Players) > 1 {
+		g.drawMatchHUD()
+	} else {
+		scoreText := fmt.Sprintf("Score: %d", g.score)
+		g.drawText(g.arena.X+2, g.arena.Y-3, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault, scoreText)
 
-	levelText := fmt.Sprintf("Nivel: %d", g.arena.Level)
-	drawText(g.arena.X+2, g.arena.Y-2, termbox.ColorCyan, termbox.ColorDefault, levelText)
+		comboText := fmt.Sprintf("Combo: x%d", g.arena.ComboSystem.CurrentCombo+1)
+		g.drawText(g.arena.X+25, g.arena.Y-2, termbox.ColorMagenta, termbox.ColorDefault, comboText)
 
-	comboText := fmt.Sprintf("Combo: x%d", g.arena.ComboSystem.CurrentCombo+1)
-	drawText(g.arena.X+25, g.arena.Y-2, termbox.ColorMagenta, termbox.ColorDefault, comboText)
+		sizeText := fmt.Sprintf("Tamanho: %d", len(g.arena.Snake.Body))
+		g.drawText(g.arena.X+45, g.arena.Y-2, termbox.ColorWhite, termbox.ColorDefault, sizeText)
 
-	sizeText := fmt.Sprintf("Tamanho: %d", len(g.arena.Snake.Body))
-	drawText(g.arena.X+45, g.arena.Y-2, termbox.ColorWhite, termbox.ColorDefault, sizeText)
+		if g.arena.BonusActive {
+			bonusText := "BONUS: " + g.arena.BonusType + "!"
+			g.drawText(g.arena.X+g.arena.Width-len(bonusText)-4, g.arena.Y-3,
+				termbox.ColorYellow|termbox.AttrBold|termbox.AttrBlink, termbox.ColorDefault, bonusText)
+		}
 
-	if g.bonusActive {
-		bonusText := "BONUS: " + g.bonusType + "!"
-		drawText(g.arena.X+g.arena.Width-len(bonusText)-4, g.arena.Y-3,
-			termbox.ColorYellow|termbox.AttrBold|termbox.AttrBlink, termbox.ColorDefault, bonusText)
+		// efeitos ativos com o tempo restante
+		if effects := g.playerEffects(g.arena.Player); len(effects) > 0 {
+			g.drawText(g.arena.X+2, g.arena.Y+g.arena.Height+2,
+				termbox.ColorCyan, termbox.ColorDefault, "Efeitos: "+strings.Join(effects, " • "))
+		}
 	}
 
+	levelText := fmt.Sprintf("Nivel: %d", g.arena.Level)
+	g.drawText(g.arena.X+2, g.arena.Y-2, termbox.ColorCyan, termbox.ColorDefault, levelText)
+
 	controls := "←↑→↓ mover • ESC sair"
-	drawText(g.arena.X+2, g.arena.Y+g.arena.Height+1,
+	if len(g.arena.Players) > 1 {
+		controls = "P1 ←↑→↓ • P2 WASD • ESC sair"
+	}
+	if g.autopilot != nil {
+		controls = "DEMONSTRACAO • qualquer tecla volta ao menu"
+	}
+	if g.watching {
+		controls = "ESPECTADOR • ESC sair"
+	}
+	g.drawText(g.arena.X+2, g.arena.Y+g.arena.Height+1,
 		termbox.ColorDarkGray, termbox.ColorDefault, controls)
 
 	foodsText := fmt.Sprintf("Frutas: %d/%d", len(g.arena.Foods), g.arena.maxFoods)
-	drawText(g.arena.X+g.arena.Width-len(foodsText)-4, g.arena.Y+g.arena.Height+1,
+	g.drawText(g.arena.X+g.arena.Width-len(foodsText)-4, g.arena.Y+g.arena.Height+1,
 		termbox.ColorWhite, termbox.ColorDefault, foodsText)
-}
 
-func (g *Game) activateBonus(bonusType string) {
-	// nao ativa novo bonus se ja estiver ativo
-	if g.bonusActive {
-		return
-	}
+	g.drawBossHealth()
 
-	g.bonusActive = true
-	g.bonusType = bonusType
-
-	if g.bonusTimer != nil {
-		g.bonusTimer.Stop()
+	// espectadores vao na linha do status, depois dele: a linha de cima
+	// e dos efeitos e da vida do boss
+	x := g.arena.X + 2
+	if g.status != "" {
+		g.drawText(x, g.arena.Y+g.arena.Height+3,
+			termbox.ColorCyan|termbox.AttrBold, termbox.ColorDefault, g.status)
+		x += len([]rune(g.status)) + 3
 	}
-
-	switch bonusType {
-	case "VELOCIDADE":
-		g.speed = 60 * time.Millisecond // dobra velocidade
-	case "CRESCIMENTO":
-		// e para crescer instantaneamente
-		for i := 0; i < 3; i++ {
-			g.arena.Snake.Grow()
-		}
-	case "PONTOS":
-		g.score += 50 // Bônus de pontos extra
+	if g.spectators > 0 {
+		g.drawText(x, g.arena.Y+g.arena.Height+3,
+			termbox.ColorMagenta, termbox.ColorDefault, fmt.Sprintf("Assistindo: %d", g.spectators))
 	}
+}
 
-	g.bonusTimer = time.AfterFunc(5*time.Second, func() {
-		g.bonusActive = false
-		g.bonusType = ""
-		if bonusType == "VELOCIDADE" {
-			g.speed = 120 * time.Millisecond // return à velocidade normal
-		}
-	})
+// efeitos ativos de um jogador com o tempo restante
+func (g *Game) playerEffects(p *Player) []string {
+	now := g.arena.Now()
+	effects := make([]string, 0, 3)
+	if p.Shield {
+		effects = append(effects, "Escudo")
+	}
+	if now.Before(p.GhostUntil) {
+		effects = append(effects, fmt.Sprintf("Fantasma %ds", secondsLeft(p.GhostUntil, now)))
+	}
+	if now.Before(p.MagnetUntil) {
+		effects = append(effects, fmt.Sprintf("Ima %ds", secondsLeft(p.MagnetUntil, now)))
+	}
+	return effects
 }
 
-func (g *Game) gameOver() {
-	// salva pontuacao
-	SaveScore(g.userID, g.score)
+// retorna true quando o jogador escolhe jogar novamente
+func (g *Game) gameOver() bool {
+	now := time.Now()
+
+	// salva replay da partida
+	g.lastReplay = g.sim.Replay()
+	g.lastReplay.Jogador = g.userID
+	g.lastReplay.Pontos = g.score
+	g.lastReplay.Data = now
+	if _, err := SaveReplay(g.lastReplay); err != nil {
+		log.Printf("Erro ao salvar replay: %v", err)
+	}
 
-	// limpar timer do bônus
-	if g.bonusTimer != nil {
-		g.bonusTimer.Stop()
+	// salva pontuacao
+	score := newScore(g.arena, 0, g.userID, now)
+	score.JogadorID = g.profileID
+	score.Replay = g.lastReplay
+	if err := g.store.Save(score); err != nil {
+		log.Printf("Erro ao salvar score: %v", err)
 	}
 
 	selected := 0
 	options := []string{"Jogar Novamente", "Ver Ranking", "Menu Principal"}
 
 	for {
-		termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+		g.r.Clear()
 
-		width, height := termbox.Size()
+		width, height := g.r.Size()
 
 		// game over
 		gameOverText := "GAME OVER"
-		drawText((width-len(gameOverText))/2, height/2-3, termbox.ColorRed|termbox.AttrBold, termbox.ColorDefault, gameOverText)
+		g.drawText((width-len(gameOverText))/2, height/2-3, termbox.ColorRed|termbox.AttrBold, termbox.ColorDefault, gameOverText)
 
 		// pontuacao final
 		scoreText := fmt.Sprintf("Score Final: %d", g.score)
-		drawText((width-len(scoreText))/2, height/2-1, termbox.ColorYellow, termbox.ColorDefault, scoreText)
+		g.drawText((width-len(scoreText))/2, height/2-1, termbox.ColorYellow, termbox.ColorDefault, scoreText)
 
 		// nível alcançado
 		levelText := fmt.Sprintf("Nivel Alcancado: %d", g.arena.Level)
-		drawText((width-len(levelText))/2, height/2, termbox.ColorCyan, termbox.ColorDefault, levelText)
+		g.drawText((width-len(levelText))/2, height/2, termbox.ColorCyan, termbox.ColorDefault, levelText)
 
 		// max combo
 		comboText := fmt.Sprintf("Max Combo: x%d", g.arena.ComboSystem.MaxCombo+1)
-		drawText((width-len(comboText))/2, height/2+1, termbox.ColorMagenta, termbox.ColorDefault, comboText)
+		g.drawText((width-len(comboText))/2, height/2+1, termbox.ColorMagenta, termbox.ColorDefault, comboText)
+
+		if len(g.arena.CheatsUsed) > 0 {
+			cheatText := "Cheats: " + strings.Join(g.arena.CheatsUsed, ", ") + " (fora do ranking)"
+			g.drawText((width-len(cheatText))/2, height/2+2, termbox.ColorRed, termbox.ColorDefault, cheatText)
+		}
 
 		// op
 		for i, option := range options {
@@ -592,15 +777,15 @@ func (g *Game) gameOver() {
 			fgColor := termbox.ColorWhite
 			if i == selected {
 				fgColor = termbox.ColorGreen | termbox.AttrBold
-				drawText(x-2, y, fgColor, termbox.ColorDefault, ">")
+				g.drawText(x-2, y, fgColor, termbox.ColorDefault, ">")
 			}
 
-			drawText(x, y, fgColor, termbox.ColorDefault, option)
+			g.drawText(x, y, fgColor, termbox.ColorDefault, option)
 		}
 
-		termbox.Flush()
+		g.r.Flush()
 
-		ev := termbox.PollEvent()
+		ev := <-g.events
 		switch ev.Type {
 		case termbox.EventKey:
 			switch ev.Key {
@@ -611,25 +796,25 @@ func (g *Game) gameOver() {
 			case termbox.KeyEnter:
 				switch selected {
 				case 0:
-					g.startGame()
-					return
+					return true
 				case 1:
 					g.showLeaderboard()
-					return
+					return false
 				case 2:
-					g.showMainMenu()
-					return
+					return false
 				}
 			case termbox.KeyEsc:
-				g.showMainMenu()
-				return
+				return false
 			}
 		}
 	}
 }
 
-func drawText(x, y int, fg, bg termbox.Attribute, text string) {
-	for i, ch := range text {
-		termbox.SetCell(x+i, y, ch, fg, bg)
+func (g *Game) drawText(x, y int, fg, bg termbox.Attribute, text string) {
+	// conta colunas, nao bytes, para nao espacar caracteres como ←↑→↓
+	col := 0
+	for _, ch := range text {
+		g.r.SetCell(x+col, y, ch, fg, bg)
+		col++
 	}
 }
@@ -0,0 +1,306 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// sem change stream o ranking e consultado de novo nesse intervalo
+const LEADERBOARD_POLL_INTERVAL = 3 * time.Second
+
+// quanto tempo um score novo fica destacado
+const LEADERBOARD_HIGHLIGHT = 5 * time.Second
+
+// linhas por pagina do ranking
+const LEADERBOARD_PAGE = 10
+
+// periodos na ordem das abas da tela de ranking
+var leaderboardPeriods = []struct{ period, label string }{
+	{PERIOD_DAY, "Hoje"},
+	{PERIOD_WEEK, "Semana"},
+	{PERIOD_MONTH, "Mes"},
+	{PERIOD_ALL, "Sempre"},
+}
+
+// estado da tela de ranking: o que a consulta pede e a pagina na tela
+type leaderboardView struct {
+	period    int // indice em leaderboardPeriods
+	page      int
+	perPlayer bool // melhor score de cada jogador em vez de todas as partidas
+	devView   bool
+	live      bool
+	scores    []Score
+	hasNext   bool
+	fresh     map[string]time.Time
+	status    string
+}
+
+func (v *leaderboardView) query() ScoreQuery {
+	since, _ := periodStart(leaderboardPeriods[v.period].period, time.Now())
+	return ScoreQuery{
+		Limit:          LEADERBOARD_PAGE + 1, // um a mais para saber se tem proxima pagina
+		Offset:         v.page * LEADERBOARD_PAGE,
+		IncludeCheated: v.devView,
+		Since:          since,
+		BestPerPlayer:  v.perPlayer,
+	}
+}
+
+// ranking ao vivo: com um ScoreWatcher (change stream do MongoDB) atualiza
+// a cada insercao, senao consulta o store periodicamente. Scores que
+// entram com a tela aberta ficam destacados por alguns segundos. As setas
+// trocam o periodo e a pagina, T alterna entre todas as partidas e o melhor
+// de cada jogador e M pula para a pagina do jogador atual
+func (g *Game) showLeaderboard() {
+	v := &leaderboardView{period: len(leaderboardPeriods) - 1, fresh: make(map[string]time.Time)}
+
+	// abrir o change stream pode demorar (replica set fora do ar), entao
+	// a tela comeca no polling e passa para o stream quando ele abrir
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opened := make(chan (<-chan Score), 1)
+	go func() {
+		changes, err := watchScores(ctx, g.store)
+		if err != nil && !errors.Is(err, ErrWatchUnsupported) && ctx.Err() == nil {
+			log.Printf("Ranking sem atualizacao ao vivo: %v — consultando a cada %s", err, LEADERBOARD_POLL_INTERVAL)
+		}
+		opened <- changes
+	}()
+	var changes <-chan Score
+
+	poll := time.NewTicker(LEADERBOARD_POLL_INTERVAL)
+	defer poll.Stop()
+	redraw := time.NewTicker(time.Second)
+	defer redraw.Stop()
+
+	var known map[string]bool
+
+	// busca a pagina; ids que nao estavam na lista anterior sao novos
+	refresh := func(highlight bool) {
+		top, err := g.store.Top(v.query())
+		if err != nil {
+			log.Printf("Erro ao buscar ranking: %v", err)
+			return
+		}
+		v.hasNext = len(top) > LEADERBOARD_PAGE
+		if v.hasNext {
+			top = top[:LEADERBOARD_PAGE]
+		}
+		now := time.Now()
+		if highlight {
+			for _, s := range top {
+				if s.ID != "" && !known[s.ID] {
+					v.fresh[s.ID] = now
+				}
+			}
+		}
+		known = make(map[string]bool, len(top))
+		for _, s := range top {
+			known[s.ID] = true
+		}
+		v.scores = top
+	}
+	// consulta nova (outro periodo, pagina ou modo): nada e destacado
+	reload := func() {
+		v.status = ""
+		refresh(false)
+	}
+	refresh(false)
+
+	for {
+		for id, since := range v.fresh {
+			if time.Since(since) > LEADERBOARD_HIGHLIGHT {
+				delete(v.fresh, id)
+			}
+		}
+		v.live = changes != nil
+		g.drawLeaderboard(v)
+
+		select {
+		case ev := <-g.events:
+			if ev.Type != termbox.EventKey {
+				continue
+			}
+			switch {
+			case ev.Key == termbox.KeyEsc:
+				return
+			case ev.Key == termbox.KeyArrowLeft:
+				v.period = (v.period - 1 + len(leaderboardPeriods)) % len(leaderboardPeriods)
+				v.page = 0
+				reload()
+			case ev.Key == termbox.KeyArrowRight:
+				v.period = (v.period + 1) % len(leaderboardPeriods)
+				v.page = 0
+				reload()
+			case ev.Key == termbox.KeyArrowUp || ev.Key == termbox.KeyPgup:
+				if v.page > 0 {
+					v.page--
+					reload()
+				}
+			case ev.Key == termbox.KeyArrowDown || ev.Key == termbox.KeyPgdn:
+				if v.hasNext {
+					v.page++
+					reload()
+				}
+			case ev.Ch == 't' || ev.Ch == 'T':
+				v.perPlayer = !v.perPlayer
+				v.page = 0
+				reload()
+			case ev.Ch == 'm' || ev.Ch == 'M':
+				g.jumpToPlayer(v)
+				refresh(false)
+			case (ev.Ch == 'd' || ev.Ch == 'D') && g.devMode:
+				v.devView = !v.devView
+				v.page = 0
+				reload()
+			}
+		case changes = <-opened:
+		case _, ok := <-changes:
+			if !ok {
+				// stream caiu: volta para o polling
+				log.Printf("Change stream do ranking encerrado — consultando a cada %s", LEADERBOARD_POLL_INTERVAL)
+				changes = nil
+				continue
+			}
+			refresh(true)
+		case <-poll.C:
+			if changes == nil {
+				refresh(true)
+			}
+		case <-redraw.C:
+		}
+	}
+}
+
+// vai para a pagina onde esta o melhor score do jogador atual
+func (g *Game) jumpToPlayer(v *leaderboardView) {
+	q := v.query()
+	q.Offset = 0
+	pos, err := playerRank(g.store, q, g.userID, g.profileID)
+	switch {
+	case err != nil:
+		if !errors.Is(err, ErrRankUnsupported) {
+			log.Printf("Erro ao buscar posicao de %s: %v", g.userID, err)
+		}
+		v.status = "Posicao indisponivel no momento"
+	case pos == 0:
+		v.status = "Voce ainda nao tem score neste periodo"
+	default:
+		v.page = (pos - 1) / LEADERBOARD_PAGE
+		v.status = fmt.Sprintf("Voce esta em %d lugar", pos)
+	}
+}
+
+func (g *Game) drawLeaderboard(v *leaderboardView) {
+	g.r.Clear()
+
+	width, height := g.r.Size()
+	title := "RANKING - " + strings.ToUpper(leaderboardPeriods[v.period].label)
+	if v.devView {
+		title += " (com cheats)"
+	}
+	g.drawText((width-len(title))/2, 1, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault, title)
+
+	// abas dos periodos
+	tabs := 0
+	for _, p := range leaderboardPeriods {
+		tabs += len(p.label) + 3
+	}
+	x := (width - tabs) / 2
+	for i, p := range leaderboardPeriods {
+		color := termbox.ColorDarkGray
+		label := " " + p.label + " "
+		if i == v.period {
+			color = termbox.ColorBlack
+			g.drawText(x, 3, color, termbox.ColorYellow, label)
+		} else {
+			g.drawText(x, 3, color, termbox.ColorDefault, label)
+		}
+		x += len(label) + 1
+	}
+
+	status := "atualiza a cada " + LEADERBOARD_POLL_INTERVAL.String()
+	if v.live {
+		status = "● ao vivo"
+	}
+	mode := "todas as partidas"
+	if v.perPlayer {
+		mode = "melhor de cada jogador"
+	}
+	status += fmt.Sprintf(" • %s • pagina %d", mode, v.page+1)
+	g.drawText((width-len([]rune(status)))/2, 4, termbox.ColorDarkGray, termbox.ColorDefault, status)
+
+	if len(v.scores) == 0 {
+		noScores := "Nenhum score registrado ainda!"
+		if v.page > 0 {
+			noScores = "Nada nesta pagina"
+		}
+		g.drawText((width-len(noScores))/2, height/2, termbox.ColorWhite, termbox.ColorDefault, noScores)
+	} else {
+		// cabeçalho
+		header := " Pos Jogador       Pontos Data"
+		g.drawText((width-len(header))/2, 6, termbox.ColorCyan|termbox.AttrBold, termbox.ColorDefault, header)
+
+		// separador
+		separator := "------------------------------"
+		g.drawText((width-len(separator))/2, 7, termbox.ColorWhite, termbox.ColorDefault, separator)
+
+		// pontuacoes
+		for i, score := range v.scores {
+			pos := v.page*LEADERBOARD_PAGE + i + 1
+
+			color := termbox.ColorWhite
+			if pos == 1 {
+				color = termbox.ColorYellow | termbox.AttrBold
+			} else if pos == 2 {
+				color = termbox.ColorWhite | termbox.AttrBold
+			} else if pos == 3 {
+				color = termbox.ColorMagenta | termbox.AttrBold
+			}
+			if isPlayerScore(score, g.userID, g.profileID) {
+				color = termbox.ColorCyan | termbox.AttrBold
+			}
+
+			playerDisplay := score.Nome
+			if len(playerDisplay) > 12 {
+				playerDisplay = playerDisplay[:12]
+			}
+
+			line := fmt.Sprintf("%3d. %-12s %6d %s",
+				pos, playerDisplay, score.Pontos, score.Data.Format("02/01"))
+			if len(score.Cheats) > 0 {
+				line += " *"
+			}
+			if !score.Verificado {
+				line += " ?" // so aparece no ranking dev
+			}
+
+			x := (width - len(header)) / 2
+			if _, ok := v.fresh[score.ID]; ok {
+				color = termbox.ColorGreen | termbox.AttrBold
+				g.drawText(x+len(line)+1, 8+i, termbox.ColorGreen|termbox.AttrBold|termbox.AttrBlink, termbox.ColorDefault, "NOVO!")
+			}
+			g.drawText(x, 8+i, color, termbox.ColorDefault, line)
+		}
+	}
+
+	if v.status != "" {
+		g.drawText((width-len([]rune(v.status)))/2, height-5, termbox.ColorCyan, termbox.ColorDefault, v.status)
+	}
+
+	controls := "←→ periodo • ↑↓ pagina • T todas/melhores • M minha posicao"
+	g.drawText((width-len([]rune(controls)))/2, height-3, termbox.ColorGreen, termbox.ColorDefault, controls)
+	backMsg := "ESC volta ao menu"
+	if g.devMode {
+		backMsg = "D alterna ranking dev • ESC volta ao menu"
+	}
+	g.drawText((width-len(backMsg))/2, height-2, termbox.ColorGreen, termbox.ColorDefault, backMsg)
+
+	g.r.Flush()
+}
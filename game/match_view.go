@@ -0,0 +1,290 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// escolhe versus ou cooperativo e joga ate voltarem ao menu
+func (g *Game) showTwoPlayerMenu() {
+	selected := 0
+	options := []string{"Versus", "Cooperativo", "Voltar"}
+	modes := []string{MODE_VERSUS, MODE_COOP}
+	help := []string{
+		"Bater no rival derruba voce e da +100 a ele. Cabeca com cabeca, a maior vence.",
+		"As cobras se atravessam. A partida acaba quando as duas caem.",
+		"",
+	}
+
+	for {
+		g.r.Clear()
+		width, height := g.r.Size()
+
+		title := "DOIS JOGADORES"
+		g.drawText((width-len(title))/2, height/2-5, termbox.ColorGreen|termbox.AttrBold, termbox.ColorDefault, title)
+
+		keys := "Jogador 1: ←↑→↓ • Jogador 2: WASD"
+		g.drawText((width-len([]rune(keys)))/2, height/2-3, termbox.ColorCyan, termbox.ColorDefault, keys)
+
+		for i, option := range options {
+			x := (width - 15) / 2
+			y := height/2 - 1 + i*2
+
+			fgColor := termbox.ColorWhite
+			if i == selected {
+				fgColor = termbox.ColorYellow | termbox.AttrBold
+				g.drawText(x-2, y, fgColor, termbox.ColorDefault, ">")
+			}
+			g.drawText(x, y, fgColor, termbox.ColorDefault, option)
+		}
+
+		if text := help[selected]; text != "" {
+			g.drawText((width-len([]rune(text)))/2, height/2+6, termbox.ColorDarkGray, termbox.ColorDefault, text)
+		}
+		g.r.Flush()
+
+		ev := <-g.events
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		switch ev.Key {
+		case termbox.KeyArrowUp:
+			selected = (selected - 1 + len(options)) % len(options)
+		case termbox.KeyArrowDown:
+			selected = (selected + 1) % len(options)
+		case termbox.KeyEnter:
+			if selected == len(options)-1 {
+				return
+			}
+			if !g.askSecondPlayer() {
+				continue
+			}
+			g.mode = modes[selected]
+			g.startGame()
+			g.mode = MODE_SOLO
+			return
+		case termbox.KeyEsc:
+			return
+		}
+	}
+}
+
+// pede o nome do jogador 2, para os scores dele irem para o ranking com
+// o nome certo. Se for o nome de um perfil desta maquina, os scores vao
+// para o perfil. False se desistiram (ESC)
+func (g *Game) askSecondPlayer() bool {
+	msg := "Nome do jogador 2:"
+	for {
+		name, ok := g.readText("DOIS JOGADORES", msg, g.p2Name)
+		if !ok {
+			return false
+		}
+		name, err := cleanProfileName(name)
+		if err != nil {
+			msg = err.Error()
+			continue
+		}
+		if strings.EqualFold(name, g.userID) {
+			msg = "O jogador 2 precisa de outro nome"
+			continue
+		}
+
+		g.p2Name, g.p2ProfileID = name, ""
+		if g.profiles != nil {
+			if p := g.profiles.Find(name); p != nil {
+				g.p2Name, g.p2ProfileID = p.Nome, p.ID
+			}
+		}
+		return true
+	}
+}
+
+// cor de cada jogador: 1 verde, 2 azul e assim por diante
+var playerColors = []termbox.Attribute{
+	termbox.ColorGreen, termbox.ColorBlue, termbox.ColorYellow, termbox.ColorMagenta,
+	termbox.ColorCyan, termbox.ColorLightRed, termbox.ColorWhite, termbox.ColorLightGreen,
+}
+
+// placar dos jogadores no topo e efeitos de cada um embaixo
+func (g *Game) drawMatchHUD() {
+	var effects []string
+
+	for i, p := range g.arena.Players {
+		text := fmt.Sprintf("P%d: %d pts • x%d • tam %d", i+1, p.Points, p.ComboSystem.CurrentCombo+1, len(p.Snake.Body))
+		if !p.Alive {
+			text = fmt.Sprintf("P%d: %d pts • fora", i+1, p.Points)
+		}
+		x, y := g.arena.X+2+i*30, g.arena.Y-3
+		if len(g.arena.Players) > 2 {
+			// muitos jogadores: so os pontos, em duas linhas
+			text = fmt.Sprintf("P%d %d", i+1, p.Points)
+			if !p.Alive {
+				text += " x"
+			}
+			x, y = g.arena.X+2+(i%4)*14, g.arena.Y-3+i/4
+		}
+		color := playerColors[i%len(playerColors)] | termbox.AttrBold
+		if p == g.arena.Player && len(g.arena.Players) > 2 {
+			color |= termbox.AttrUnderline
+		}
+		g.drawText(x, y, color, termbox.ColorDefault, text)
+
+		mine := g.playerEffects(p)
+		if p.BonusActive {
+			mine = append(mine, p.BonusType)
+		}
+		if len(mine) > 0 {
+			effects = append(effects, fmt.Sprintf("P%d %s", i+1, strings.Join(mine, ", ")))
+		}
+	}
+
+	if g.arena.Mode == MODE_COOP {
+		team := 0
+		for _, p := range g.arena.Players {
+			team += p.Points
+		}
+		teamText := fmt.Sprintf("Time: %d", team)
+		g.drawText(g.arena.X+g.arena.Width-len(teamText)-4, g.arena.Y-2, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault, teamText)
+	}
+
+	if len(effects) > 0 {
+		g.drawText(g.arena.X+2, g.arena.Y+g.arena.Height+2,
+			termbox.ColorCyan, termbox.ColorDefault, "Efeitos: "+strings.Join(effects, " • "))
+	}
+}
+
+// nomes dos jogadores da partida local (na partida solo e so o id)
+func (g *Game) matchPlayerNames() []string {
+	names := []string{g.userID}
+	if len(g.arena.Players) > 1 {
+		names = append(names, g.p2Name)
+	}
+	return names
+}
+
+// grava um score por jogador, ligados pela partida
+func (g *Game) saveMatchScores(names []string, now time.Time) {
+	match := newScoreID()
+	for i := range g.arena.Players {
+		score := newScore(g.arena, i, names[i], now)
+		score.JogadorID = g.profileID
+		if i == 1 {
+			score.JogadorID = g.p2ProfileID
+		}
+		score.Replay = g.lastReplay
+		score.Partida = match
+		if err := g.store.Save(score); err != nil {
+			log.Printf("Erro ao salvar score do jogador %d: %v", i+1, err)
+		}
+	}
+}
+
+// manda o tick da partida local para os espectadores
+func (g *Game) broadcastSpectators() {
+	if g.spectate == nil {
+		return
+	}
+	names := g.matchPlayerNames()
+	g.spectate.broadcast(g.arena, g.sim.TickCount, names)
+	g.spectators = g.spectate.count()
+}
+
+// placar final da partida local para os espectadores
+func (g *Game) roundResults() []Score {
+	names := g.matchPlayerNames()
+	results := make([]Score, len(g.arena.Players))
+	for i := range g.arena.Players {
+		results[i] = newScore(g.arena, i, names[i], time.Now())
+	}
+	return results
+}
+
+// fim da partida de dois jogadores. Partida interrompida no ESC nao tem
+// vencedor nem vai para o ranking. Retorna true para jogar de novo
+func (g *Game) matchOver() bool {
+	now := time.Now()
+	names := g.matchPlayerNames()
+
+	g.lastReplay = g.sim.Replay()
+	g.lastReplay.Jogador = names[0]
+	g.lastReplay.Jogadores = names
+	g.lastReplay.Pontos = g.arena.Players[0].Points
+	g.lastReplay.Data = now
+	if _, err := SaveReplay(g.lastReplay); err != nil {
+		log.Printf("Erro ao salvar replay: %v", err)
+	}
+
+	// interrompida no ESC o replay fica, mas os scores nao
+	if !g.roundQuit {
+		g.saveMatchScores(names, now)
+	}
+
+	result := "FIM DA PARTIDA"
+	switch {
+	case g.roundQuit:
+		result = "PARTIDA INTERROMPIDA"
+	case g.arena.Mode == MODE_VERSUS:
+		result = "EMPATE"
+		for i, p := range g.arena.Players {
+			if p.Alive {
+				result = fmt.Sprintf("JOGADOR %d VENCEU!", i+1)
+			}
+		}
+	}
+
+	selected := 0
+	options := []string{"Jogar Novamente", "Menu Principal"}
+
+	for {
+		g.r.Clear()
+		width, height := g.r.Size()
+
+		g.drawText((width-len(result))/2, height/2-4, termbox.ColorRed|termbox.AttrBold, termbox.ColorDefault, result)
+
+		team := 0
+		for i, p := range g.arena.Players {
+			team += p.Points
+			line := fmt.Sprintf("Jogador %d: %d pts • max combo x%d", i+1, p.Points, p.ComboSystem.MaxCombo+1)
+			g.drawText((width-len(line))/2, height/2-2+i, termbox.ColorYellow, termbox.ColorDefault, line)
+		}
+		if g.arena.Mode == MODE_COOP {
+			teamText := fmt.Sprintf("Time: %d pts", team)
+			g.drawText((width-len(teamText))/2, height/2, termbox.ColorCyan|termbox.AttrBold, termbox.ColorDefault, teamText)
+		}
+
+		levelText := fmt.Sprintf("Nivel Alcancado: %d", g.arena.Level)
+		g.drawText((width-len(levelText))/2, height/2+1, termbox.ColorCyan, termbox.ColorDefault, levelText)
+
+		for i, option := range options {
+			x := (width - 20) / 2
+			y := height/2 + 3 + i*2
+
+			fgColor := termbox.ColorWhite
+			if i == selected {
+				fgColor = termbox.ColorGreen | termbox.AttrBold
+				g.drawText(x-2, y, fgColor, termbox.ColorDefault, ">")
+			}
+			g.drawText(x, y, fgColor, termbox.ColorDefault, option)
+		}
+		g.r.Flush()
+
+		ev := <-g.events
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		switch ev.Key {
+		case termbox.KeyArrowUp:
+			selected = (selected - 1 + len(options)) % len(options)
+		case termbox.KeyArrowDown:
+			selected = (selected + 1) % len(options)
+		case termbox.KeyEnter:
+			return selected == 0
+		case termbox.KeyEsc:
+			return false
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+// ESC no meio da partida de dois jogadores nao declara vencedor nem grava
+// scores
+func TestMatchQuitIsNotAResult(t *testing.T) {
+	t.Setenv("SNAKE_DATA_DIR", t.TempDir())
+	r := NewRecordingRenderer(80, 40)
+	store := NewMemoryStore()
+	g, _ := NewGame(Options{Renderer: r, Store: store, Name: "teste"})
+	g.mode = MODE_VERSUS
+	g.p2Name = "rival"
+
+	again := make(chan bool)
+	go func() { again <- g.playRound() }()
+
+	g.events <- termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEsc}
+	// so passa quando a tela de fim ja foi desenhada e espera uma tecla
+	g.events <- termbox.Event{Type: termbox.EventKey, Key: termbox.KeyArrowDown}
+	g.events <- termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEsc}
+	if <-again {
+		t.Error("ESC na tela de fim deveria voltar ao menu")
+	}
+
+	if !r.Contains("PARTIDA INTERROMPIDA") || r.Contains("VENCEU") {
+		t.Errorf("tela de fim:\n%s", r.Screen())
+	}
+	if scores, _ := store.Top(ScoreQuery{IncludeCheated: true}); len(scores) != 0 {
+		t.Errorf("%d scores gravados, esperado nenhum", len(scores))
+	}
+}
+
+// o jogador 2 joga com o nome que digitou, e com o perfil local que tiver
+// esse nome
+func TestAskSecondPlayer(t *testing.T) {
+	t.Setenv("SNAKE_DATA_DIR", t.TempDir())
+	book, _ := LoadProfiles(profilesPath())
+	ana, _ := book.Add("Ana")
+	bia, _ := book.Add("Bia")
+	book.Switch(ana)
+
+	r := NewRecordingRenderer(80, 40)
+	g, _ := NewGame(Options{Renderer: r, Store: NewMemoryStore()})
+	g.useProfile(book.Current())
+
+	typed := func(text string) {
+		for _, ch := range text {
+			g.events <- termbox.Event{Type: termbox.EventKey, Ch: ch}
+		}
+		g.events <- termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEnter}
+	}
+	done := make(chan bool)
+	go func() { done <- g.askSecondPlayer() }()
+	typed("ana")
+	typed("bia")
+	if !<-done {
+		t.Fatal("askSecondPlayer desistiu")
+	}
+	if g.p2Name != "Bia" || g.p2ProfileID != bia.ID {
+		t.Errorf("jogador 2: %q (%s), esperado Bia (%s)", g.p2Name, g.p2ProfileID, bia.ID)
+	}
+
+	g.sim = NewMatchSimulation(60, 25, 1, MODE_VERSUS, 2)
+	g.arena = g.sim.Arena
+	g.saveMatchScores(g.matchPlayerNames(), g.arena.Now())
+	scores, _ := g.store.Top(ScoreQuery{IncludeCheated: true})
+	ids := map[string]string{}
+	for _, s := range scores {
+		ids[s.Nome] = s.JogadorID
+	}
+	if ids["Ana"] != ana.ID || ids["Bia"] != bia.ID {
+		t.Errorf("perfis dos scores: %v", ids)
+	}
+}
@@ -0,0 +1,298 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// URI do replica set do docker-compose, usada com DOCKER_ENV sem MONGO_URI
+const DOCKER_MONGO_URI = "mongodb://mongo1:27017,mongo2:27017,mongo3:27017/trabalho?replicaSet=rs0&connect=direct"
+
+// como o jogo conversa com o MongoDB. Cada campo vem, do mais fraco para o
+// mais forte, do padrao, do arquivo de config, do ambiente ou de uma flag
+type MongoConfig struct {
+	URI            string
+	WriteConcern   string // 1, majority ou journaled
+	ReadPreference string // primary, primaryPreferred, secondary, secondaryPreferred ou nearest
+	ReadConcern    string // local, available, majority ou linearizable
+
+	ConnectRetries int           // tentativas do WaitReady
+	RetryInterval  time.Duration // espera entre as tentativas
+	ConnectWait    time.Duration // prazo do WaitReady inteiro, somando as tentativas
+	RetryWrites    bool          // o driver repete uma escrita que falhou por troca de primario
+	RetryReads     bool
+
+	ConnectTimeout         time.Duration // conexao e cada ping do WaitReady
+	ServerSelectionTimeout time.Duration // espera por um membro que atenda a read preference
+	WriteTimeout           time.Duration // Save e SaveProfile
+	ReadTimeout            time.Duration // ranking e estatisticas
+
+	Source map[string]string // de onde veio cada campo, pela chave do arquivo
+}
+
+// uma configuracao: chave no arquivo, variavel de ambiente e o campo
+type mongoSetting struct {
+	key   string
+	env   string
+	usage string
+	field func(c *MongoConfig) any // ponteiro para o campo
+}
+
+var mongoSettings = []mongoSetting{
+	{"uri", "MONGO_URI", "URI do MongoDB (vazio: arquivo local)",
+		func(c *MongoConfig) any { return &c.URI }},
+	{"write_concern", "MONGO_WRITE_CONCERN", "write concern: 1, majority ou journaled",
+		func(c *MongoConfig) any { return &c.WriteConcern }},
+	{"read_preference", "MONGO_READ_PREFERENCE", "read preference: primary, primaryPreferred, secondary, secondaryPreferred ou nearest",
+		func(c *MongoConfig) any { return &c.ReadPreference }},
+	{"read_concern", "MONGO_READ_CONCERN", "read concern: local, available, majority ou linearizable",
+		func(c *MongoConfig) any { return &c.ReadConcern }},
+	{"connect_retries", "MONGO_CONNECT_RETRIES", "tentativas de conexao ao iniciar",
+		func(c *MongoConfig) any { return &c.ConnectRetries }},
+	{"retry_interval", "MONGO_RETRY_INTERVAL", "espera entre as tentativas de conexao",
+		func(c *MongoConfig) any { return &c.RetryInterval }},
+	{"connect_wait", "MONGO_CONNECT_WAIT", "prazo total da espera pelo replica set ao iniciar",
+		func(c *MongoConfig) any { return &c.ConnectWait }},
+	{"retry_writes", "MONGO_RETRY_WRITES", "repete escritas que falharam por troca de primario",
+		func(c *MongoConfig) any { return &c.RetryWrites }},
+	{"retry_reads", "MONGO_RETRY_READS", "repete leituras que falharam por troca de primario",
+		func(c *MongoConfig) any { return &c.RetryReads }},
+	{"connect_timeout", "MONGO_CONNECT_TIMEOUT", "timeout de conexao e de cada ping",
+		func(c *MongoConfig) any { return &c.ConnectTimeout }},
+	{"server_selection_timeout", "MONGO_SERVER_SELECTION_TIMEOUT", "espera por um membro do replica set disponivel",
+		func(c *MongoConfig) any { return &c.ServerSelectionTimeout }},
+	{"write_timeout", "MONGO_WRITE_TIMEOUT", "timeout de cada escrita",
+		func(c *MongoConfig) any { return &c.WriteTimeout }},
+	{"read_timeout", "MONGO_READ_TIMEOUT", "timeout de cada consulta",
+		func(c *MongoConfig) any { return &c.ReadTimeout }},
+}
+
+func (s mongoSetting) set(c *MongoConfig, v string) (err error) {
+	switch f := s.field(c).(type) {
+	case *string:
+		*f = v
+	case *int:
+		*f, err = strconv.Atoi(v)
+	case *bool:
+		*f, err = strconv.ParseBool(v)
+	case *time.Duration:
+		*f, err = time.ParseDuration(v)
+	}
+	return err
+}
+
+func (s mongoSetting) get(c *MongoConfig) string {
+	switch f := s.field(c).(type) {
+	case *string:
+		return *f
+	case *int:
+		return strconv.Itoa(*f)
+	case *bool:
+		return strconv.FormatBool(*f)
+	case *time.Duration:
+		return f.String()
+	}
+	return ""
+}
+
+// os valores de antes da configuracao existir, exceto o write concern, que
+// fica explicito em majority (o padrao do servidor num replica set)
+func defaultMongoConfig() MongoConfig {
+	return MongoConfig{
+		WriteConcern:           "majority",
+		ReadPreference:         "primary",
+		ReadConcern:            "local",
+		ConnectRetries:         30,
+		RetryInterval:          2 * time.Second,
+		ConnectWait:            30 * time.Second,
+		RetryWrites:            true,
+		RetryReads:             true,
+		ConnectTimeout:         5 * time.Second,
+		ServerSelectionTimeout: 30 * time.Second,
+		WriteTimeout:           5 * time.Second,
+		ReadTimeout:            10 * time.Second,
+		Source:                 make(map[string]string),
+	}
+}
+
+// flags passadas na linha de comando, pela chave do arquivo. So as que
+// foram usadas entram aqui, para nao esconder o ambiente com o padrao
+var mongoFlagValues = make(map[string]string)
+
+// arquivo de config escolhido por -mongo-config
+var mongoConfigFlag string
+
+type mongoFlag struct{ key string }
+
+func (f mongoFlag) String() string { return mongoFlagValues[f.key] }
+
+func (f mongoFlag) Set(v string) error {
+	mongoFlagValues[f.key] = v
+	return nil
+}
+
+// registra -mongo-config e uma flag -mongo-<chave> para cada configuracao
+// do MongoDB. Valem para os OpenScoreStore depois do Parse
+func MongoFlags(fs *flag.FlagSet) {
+	fs.StringVar(&mongoConfigFlag, "mongo-config", "", "arquivo JSON com a config do MongoDB (padrao: SNAKE_MONGO_CONFIG ou mongo.json no diretorio de dados)")
+	def := defaultMongoConfig()
+	for _, s := range mongoSettings {
+		name := "mongo-" + strings.ReplaceAll(s.key, "_", "-")
+		usage := s.usage + " (ou " + s.env
+		if v := s.get(&def); v != "" {
+			usage += ", padrao " + v
+		}
+		fs.Var(mongoFlag{s.key}, name, usage+")")
+	}
+}
+
+func mongoConfigPath() string {
+	if mongoConfigFlag != "" {
+		return mongoConfigFlag
+	}
+	if path := os.Getenv("SNAKE_MONGO_CONFIG"); path != "" {
+		return path
+	}
+	return filepath.Join(dataDir(), "mongo.json")
+}
+
+// junta padrao, arquivo, ambiente e flags e confere os valores
+func LoadMongoConfig() (MongoConfig, error) {
+	c := defaultMongoConfig()
+
+	path := mongoConfigPath()
+	file := make(map[string]any)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return c, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return c, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	for _, s := range mongoSettings {
+		value, source := "", ""
+		if v, ok := file[s.key]; ok {
+			value, source = fmt.Sprint(v), "arquivo"
+		}
+		if v := os.Getenv(s.env); v != "" {
+			value, source = v, "ambiente"
+		}
+		if v, ok := mongoFlagValues[s.key]; ok {
+			value, source = v, "flag"
+		}
+		if source == "" {
+			continue
+		}
+		if err := s.set(&c, value); err != nil {
+			return c, fmt.Errorf("%s invalido (%s): %w", s.key, source, err)
+		}
+		c.Source[s.key] = source
+	}
+
+	if c.URI == "" && os.Getenv("DOCKER_ENV") != "" {
+		c.URI = DOCKER_MONGO_URI
+		c.Source["uri"] = "DOCKER_ENV"
+	}
+	return c, c.validate()
+}
+
+func (c MongoConfig) validate() error {
+	if _, err := c.writeConcern(); err != nil {
+		return err
+	}
+	pref, err := c.readPref()
+	if err != nil {
+		return err
+	}
+	if _, err := c.readConcern(); err != nil {
+		return err
+	}
+	if c.ReadConcern == "linearizable" && pref.Mode() != readpref.PrimaryMode {
+		return errors.New("read concern linearizable so funciona com read preference primary")
+	}
+	if c.ConnectRetries < 1 {
+		return errors.New("connect_retries deve ser pelo menos 1")
+	}
+	if c.ConnectWait <= 0 {
+		return errors.New("connect_wait deve ser maior que zero")
+	}
+	return nil
+}
+
+func (c MongoConfig) writeConcern() (*writeconcern.WriteConcern, error) {
+	switch c.WriteConcern {
+	case "1":
+		return writeconcern.W1(), nil
+	case "majority":
+		return writeconcern.Majority(), nil
+	case "journaled":
+		// w:1 e so confirma depois de gravar no journal do primario
+		journal := true
+		return &writeconcern.WriteConcern{W: 1, Journal: &journal}, nil
+	}
+	return nil, fmt.Errorf("write concern desconhecido: %q (use 1, majority ou journaled)", c.WriteConcern)
+}
+
+func (c MongoConfig) readPref() (*readpref.ReadPref, error) {
+	mode, err := readpref.ModeFromString(c.ReadPreference)
+	if err != nil {
+		return nil, fmt.Errorf("read preference desconhecida: %q", c.ReadPreference)
+	}
+	return readpref.New(mode)
+}
+
+func (c MongoConfig) readConcern() (*readconcern.ReadConcern, error) {
+	switch c.ReadConcern {
+	case "local", "available", "majority", "linearizable":
+		return &readconcern.ReadConcern{Level: c.ReadConcern}, nil
+	}
+	return nil, fmt.Errorf("read concern desconhecido: %q (use local, available, majority ou linearizable)", c.ReadConcern)
+}
+
+// opcoes do cliente; o que a config define vale mais que a query da URI
+func (c MongoConfig) clientOptions() *options.ClientOptions {
+	wc, _ := c.writeConcern()
+	rp, _ := c.readPref()
+	rc, _ := c.readConcern()
+	return options.Client().
+		ApplyURI(c.URI).
+		SetWriteConcern(wc).
+		SetReadPreference(rp).
+		SetReadConcern(rc).
+		SetRetryWrites(c.RetryWrites).
+		SetRetryReads(c.RetryReads).
+		SetConnectTimeout(c.ConnectTimeout).
+		SetServerSelectionTimeout(c.ServerSelectionTimeout)
+}
+
+// a URI sem a senha, para logs e para a tela de diagnostico
+func (c MongoConfig) safeURI() string {
+	scheme, rest, ok := strings.Cut(c.URI, "://")
+	if !ok {
+		return c.URI
+	}
+	hosts := len(rest)
+	if end := strings.IndexAny(rest, "/?"); end >= 0 {
+		hosts = end
+	}
+	if at := strings.LastIndex(rest[:hosts], "@"); at >= 0 {
+		user, _, _ := strings.Cut(rest[:at], ":")
+		rest = user + ":***" + rest[at:]
+	}
+	return scheme + "://" + rest
+}
@@ -0,0 +1,116 @@
+package game
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ambiente limpo: sem variaveis MONGO_*, sem flags de outro teste e com o
+// arquivo de config (se tiver) num diretorio temporario
+func mongoConfigEnv(t *testing.T, file string, flags ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("SNAKE_DATA_DIR", dir)
+	t.Setenv("SNAKE_MONGO_CONFIG", "")
+	t.Setenv("DOCKER_ENV", "")
+	for _, s := range mongoSettings {
+		t.Setenv(s.env, "")
+	}
+	if file != "" {
+		if err := os.WriteFile(filepath.Join(dir, "mongo.json"), []byte(file), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mongoFlagValues = make(map[string]string)
+	mongoConfigFlag = ""
+	t.Cleanup(func() {
+		mongoFlagValues = make(map[string]string)
+		mongoConfigFlag = ""
+	})
+	fs := flag.NewFlagSet("teste", flag.ContinueOnError)
+	MongoFlags(fs)
+	if err := fs.Parse(flags); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// padrao < arquivo < ambiente < flag, e Source diz de onde veio cada um
+func TestLoadMongoConfigPrecedence(t *testing.T) {
+	mongoConfigEnv(t,
+		`{"uri": "mongodb://arquivo", "write_concern": "1", "read_timeout": "3s", "connect_retries": 5}`,
+		"-mongo-read-timeout", "7s")
+	t.Setenv("MONGO_WRITE_CONCERN", "journaled")
+	t.Setenv("MONGO_READ_TIMEOUT", "4s")
+
+	c, err := LoadMongoConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := []struct {
+		key, got, want, source string
+	}{
+		{"uri", c.URI, "mongodb://arquivo", "arquivo"},
+		{"connect_retries", strconv.Itoa(c.ConnectRetries), "5", "arquivo"},
+		{"write_concern", c.WriteConcern, "journaled", "ambiente"},
+		{"read_timeout", c.ReadTimeout.String(), (7 * time.Second).String(), "flag"},
+		{"read_preference", c.ReadPreference, "primary", ""},
+	}
+	for _, ch := range checks {
+		if ch.got != ch.want {
+			t.Errorf("%s: %q, esperado %q", ch.key, ch.got, ch.want)
+		}
+		if c.Source[ch.key] != ch.source {
+			t.Errorf("%s veio de %q, esperado %q", ch.key, c.Source[ch.key], ch.source)
+		}
+	}
+}
+
+func TestLoadMongoConfigDefaults(t *testing.T) {
+	mongoConfigEnv(t, "")
+	c, err := LoadMongoConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.URI != "" || len(c.Source) != 0 {
+		t.Errorf("sem config: uri %q, fontes %v", c.URI, c.Source)
+	}
+
+	t.Setenv("DOCKER_ENV", "1")
+	c, _ = LoadMongoConfig()
+	if c.URI != DOCKER_MONGO_URI || c.Source["uri"] != "DOCKER_ENV" {
+		t.Errorf("DOCKER_ENV: uri %q de %q", c.URI, c.Source["uri"])
+	}
+}
+
+func TestLoadMongoConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		flags []string
+		want  string // trecho da mensagem de erro
+	}{
+		{"write concern desconhecido", "", []string{"-mongo-write-concern", "2"}, "write concern"},
+		{"read preference desconhecida", `{"read_preference": "qualquer"}`, nil, "read preference"},
+		{"read concern desconhecido", `{"read_concern": "snapshot"}`, nil, "read concern"},
+		{"linearizable fora do primario", `{"read_concern": "linearizable"}`, []string{"-mongo-read-preference", "secondary"}, "linearizable"},
+		{"duracao invalida", "", []string{"-mongo-read-timeout", "10"}, "read_timeout invalido (flag)"},
+		{"numero invalido", `{"connect_retries": "muitas"}`, nil, "connect_retries invalido (arquivo)"},
+		{"sem tentativas", `{"connect_retries": 0}`, nil, "connect_retries"},
+		{"JSON quebrado", `{"uri": `, nil, "mongo.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mongoConfigEnv(t, tt.file, tt.flags...)
+			_, err := LoadMongoConfig()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("erro: %v, esperado com %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// protocolo do servidor: uma mensagem JSON por linha, nos dois sentidos
+const (
+	// cliente -> servidor
+	MSG_JOIN  = "join"  // entra na fila da proxima partida
+	MSG_INPUT = "input" // direcao da cobra
+	MSG_PING  = "ping"
+	MSG_WATCH = "watch" // assiste a partida sem jogar
+
+	// servidor -> cliente
+	MSG_LOBBY = "lobby" // quem esta esperando a partida
+	MSG_START = "start" // partida comecou, com a posicao do jogador
+	MSG_STATE = "state" // snapshot completo ou delta
+	MSG_PONG  = "pong"
+	MSG_OVER  = "over" // resultado final
+	MSG_ERROR = "error"
+)
+
+// snapshot completo a cada KEYFRAME_TICKS, deltas no meio
+const KEYFRAME_TICKS = 25
+
+type NetMessage struct {
+	Type    string    `json:"type"`
+	Name    string    `json:"name,omitempty"`
+	Seq     int       `json:"seq,omitempty"` // entradas: numero; estado: ultima entrada aplicada
+	Dir     string    `json:"dir,omitempty"` // up, down, left, right
+	Slot    int       `json:"slot,omitempty"`
+	Time    int64     `json:"time,omitempty"` // ping/pong: eco do relogio do cliente
+	Lobby   []string  `json:"lobby,omitempty"`
+	Needed  int       `json:"needed,omitempty"`
+	State   *Snapshot `json:"state,omitempty"`
+	Results []Score   `json:"results,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// estado de um jogador como o cliente precisa para desenhar
+type PlayerState struct {
+	Name        string    `json:"name"`
+	Body        []Coord   `json:"body"`
+	Dir         Coord     `json:"dir"`
+	Points      int       `json:"points"`
+	Combo       int       `json:"combo"`
+	MaxCombo    int       `json:"max_combo"`
+	Alive       bool      `json:"alive"`
+	Shield      bool      `json:"shield,omitempty"`
+	GhostUntil  time.Time `json:"ghost,omitempty"`
+	MagnetUntil time.Time `json:"magnet,omitempty"`
+	Bonus       string    `json:"bonus,omitempty"`
+}
+
+// Snapshot e o estado da arena num tick. Num delta as listas que nao
+// mudaram desde o ultimo envio ficam nil; as cobras vao sempre
+type Snapshot struct {
+	Tick     int           `json:"tick"`
+	Keyframe bool          `json:"key,omitempty"`
+	Width    int           `json:"w"`
+	Height   int           `json:"h"`
+	Mode     string        `json:"mode"`
+	Level    int           `json:"level"`
+	MaxFoods int           `json:"max_foods"`
+	Players  []PlayerState `json:"players"`
+
+	Spectators int `json:"spectators,omitempty"`
+
+	Foods     *[]*Food       `json:"foods,omitempty"`
+	PowerUps  *[]*PowerUp    `json:"powerups,omitempty"`
+	Obstacles *[]*Obstacle   `json:"obstacles,omitempty"`
+	Bosses    *[]*Boss       `json:"bosses,omitempty"`
+	Messages  *[]GameMessage `json:"messages,omitempty"`
+}
+
+// guarda o que foi enviado por ultimo para montar deltas
+type snapshotEncoder struct {
+	last map[string][]byte
+}
+
+func newSnapshotEncoder() *snapshotEncoder {
+	return &snapshotEncoder{last: make(map[string][]byte)}
+}
+
+// snapshot da arena; keyframe manda tudo, senao so as listas que mudaram
+func (e *snapshotEncoder) encode(a *Arena, tick int, names []string, keyframe bool) *Snapshot {
+	s := &Snapshot{
+		Tick:     tick,
+		Keyframe: keyframe,
+		Width:    a.Width,
+		Height:   a.Height,
+		Mode:     a.Mode,
+		Level:    a.Level,
+		MaxFoods: a.maxFoods,
+	}
+	for i, p := range a.Players {
+		ps := PlayerState{
+			Body:        p.Snake.Body,
+			Dir:         p.Snake.Dir,
+			Points:      p.Points,
+			Combo:       p.ComboSystem.CurrentCombo,
+			MaxCombo:    p.ComboSystem.MaxCombo,
+			Alive:       p.Alive,
+			Shield:      p.Shield,
+			GhostUntil:  p.GhostUntil,
+			MagnetUntil: p.MagnetUntil,
+		}
+		if p.BonusActive {
+			ps.Bonus = p.BonusType
+		}
+		if i < len(names) {
+			ps.Name = names[i]
+		}
+		s.Players = append(s.Players, ps)
+	}
+
+	// cada lista so entra se o JSON dela mudou (ou no keyframe)
+	changed := func(key string, v interface{}) bool {
+		data, _ := json.Marshal(v)
+		if !keyframe && bytes.Equal(e.last[key], data) {
+			return false
+		}
+		e.last[key] = data
+		return true
+	}
+	if foods := a.Foods; changed("foods", foods) {
+		s.Foods = &foods
+	}
+	if powerUps := a.PowerUps; changed("powerups", powerUps) {
+		s.PowerUps = &powerUps
+	}
+	if obstacles := a.Obstacles; changed("obstacles", obstacles) {
+		s.Obstacles = &obstacles
+	}
+	if bosses := a.Bosses; changed("bosses", bosses) {
+		s.Bosses = &bosses
+	}
+	if messages := a.Messages; changed("messages", messages) {
+		s.Messages = &messages
+	}
+	return s
+}
+
+// arena so para desenho, montada a partir dos snapshots recebidos
+func newMirrorArena() *Arena {
+	return &Arena{
+		X:      2,
+		Y:      3,
+		Player: newPlayer(newSnake()),
+		Mode:   MODE_ONLINE,
+		Level:  1,
+		clock:  NewManualClock(simEpoch),
+	}
+}
+
+// aplica o snapshot na arena espelho; delta sem keyframe anterior so
+// atualiza o que veio
+func (s *Snapshot) applyTo(a *Arena, slot int) {
+	a.Width, a.Height = s.Width, s.Height
+	a.Mode = s.Mode
+	a.Level = s.Level
+	a.maxFoods = s.MaxFoods
+	a.clock.(*ManualClock).now = simEpoch.Add(time.Duration(s.Tick) * TICK_RATE)
+
+	a.Players = a.Players[:0]
+	for _, ps := range s.Players {
+		p := newPlayer(&Snake{Body: ps.Body, Dir: ps.Dir})
+		p.Points = ps.Points
+		p.ComboSystem.CurrentCombo = ps.Combo
+		p.ComboSystem.MaxCombo = ps.MaxCombo
+		p.Alive = ps.Alive
+		p.Shield = ps.Shield
+		p.GhostUntil = ps.GhostUntil
+		p.MagnetUntil = ps.MagnetUntil
+		p.BonusActive = ps.Bonus != ""
+		p.BonusType = ps.Bonus
+		a.Players = append(a.Players, p)
+	}
+	if slot >= 0 && slot < len(a.Players) {
+		a.Player = a.Players[slot]
+	} else if len(a.Players) > 0 {
+		a.Player = a.Players[0]
+	}
+
+	if s.Foods != nil {
+		a.Foods = *s.Foods
+	}
+	if s.PowerUps != nil {
+		a.PowerUps = *s.PowerUps
+	}
+	if s.Obstacles != nil {
+		a.Obstacles = *s.Obstacles
+	}
+	if s.Bosses != nil {
+		a.Bosses = *s.Bosses
+	}
+	if s.Messages != nil {
+		a.Messages = *s.Messages
+	}
+}
+
+// direcoes do protocolo
+var netDirs = map[string]Input{
+	"up":    INPUT_UP,
+	"down":  INPUT_DOWN,
+	"left":  INPUT_LEFT,
+	"right": INPUT_RIGHT,
+}
+
+func netDirName(in Input) string {
+	for name, d := range netDirs {
+		if d == in {
+			return name
+		}
+	}
+	return ""
+}
+
+// le mensagens linha a linha ate o fim da conexao
+func readNetMessages(r io.Reader, fn func(NetMessage) bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var msg NetMessage
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			return err
+		}
+		if !fn(msg) {
+			return nil
+		}
+	}
+	return sc.Err()
+}
+
+func encodeNetMessage(msg NetMessage) []byte {
+	data, _ := json.Marshal(msg)
+	return append(data, '\n')
+}
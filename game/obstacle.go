@@ -0,0 +1,162 @@
+package game
+
+import (
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// chance de um obstaculo novo ser movel, cresce com o nivel a partir do 4
+func (a *Arena) movingObstacleChance() float64 {
+	if a.Level < 4 {
+		return 0
+	}
+	chance := 0.15 * float64(a.Level-3)
+	if chance > 0.6 {
+		chance = 0.6
+	}
+	return chance
+}
+
+// cria um obstaculo que patrulha uma linha (vai e volta) ou um retangulo (em loop)
+func (a *Arena) placeMovingObstacle() bool {
+	for attempts := 0; attempts < 30; attempts++ {
+		var path []Coord
+		loop := a.rng.Float32() < 0.4
+
+		x := a.rng.Intn(a.Width-4) + a.X + 2
+		y := a.rng.Intn(a.Height-4) + a.Y + 2
+		if loop {
+			w := 3 + a.rng.Intn(4)
+			h := 3 + a.rng.Intn(3)
+			path = rectanglePath(Coord{X: x, Y: y}, w, h)
+		} else {
+			length := 5 + a.rng.Intn(6)
+			dir := Coord{X: 1}
+			if a.rng.Intn(2) == 0 {
+				dir = Coord{Y: 1}
+			}
+			for i := 0; i < length; i++ {
+				path = append(path, Coord{X: x + dir.X*i, Y: y + dir.Y*i})
+			}
+		}
+
+		if !a.isPatrolPathValid(path) {
+			continue
+		}
+
+		speed := 300*time.Millisecond - time.Duration(a.Level)*10*time.Millisecond
+		if speed < 150*time.Millisecond {
+			speed = 150 * time.Millisecond
+		}
+
+		a.Obstacles = append(a.Obstacles, &Obstacle{
+			Coord:        path[0],
+			ObstacleType: OBSTACLE_MOVING,
+			IsTemporary:  a.rng.Float32() < 0.3,
+			SpawnTime:    a.Now(),
+			Lifetime:     time.Duration(15+a.rng.Intn(20)) * time.Second,
+			Path:         path,
+			PathStep:     1,
+			Loop:         loop,
+			Speed:        speed,
+			LastMove:     a.Now(),
+		})
+		return true
+	}
+	return false
+}
+
+// perimetro de um retangulo a partir do canto superior esquerdo, sentido horario
+func rectanglePath(corner Coord, w, h int) []Coord {
+	var path []Coord
+	for x := 0; x < w; x++ {
+		path = append(path, Coord{X: corner.X + x, Y: corner.Y})
+	}
+	for y := 1; y < h; y++ {
+		path = append(path, Coord{X: corner.X + w - 1, Y: corner.Y + y})
+	}
+	for x := w - 2; x >= 0; x-- {
+		path = append(path, Coord{X: corner.X + x, Y: corner.Y + h - 1})
+	}
+	for y := h - 2; y > 0; y-- {
+		path = append(path, Coord{X: corner.X, Y: corner.Y + y})
+	}
+	return path
+}
+
+// a rota inteira precisa estar livre e longe da cabeca da cobra
+func (a *Arena) isPatrolPathValid(path []Coord) bool {
+	for _, c := range path {
+		if a.isWall(c) || !a.isPositionValid(c) {
+			return false
+		}
+		head := a.nearestPlayerHead(c)
+		if abs(c.X-head.X)+abs(c.Y-head.Y) < 5 {
+			return false
+		}
+	}
+	return true
+}
+
+// anda os obstaculos moveis uma casa na rota. Um obstaculo bloqueado pela
+// cobra, por um estrangeiro ou por outro obstaculo espera a vez
+func (a *Arena) moveObstacles() {
+	now := a.Now()
+	for _, obs := range a.Obstacles {
+		if obs.ObstacleType != OBSTACLE_MOVING || len(obs.Path) < 2 || now.Sub(obs.LastMove) < obs.Speed {
+			continue
+		}
+
+		next := obs.PathIndex + obs.PathStep
+		if obs.Loop {
+			next = (next + len(obs.Path)) % len(obs.Path)
+		} else if next < 0 || next >= len(obs.Path) {
+			// fim da linha: volta
+			obs.PathStep = -obs.PathStep
+			next = obs.PathIndex + obs.PathStep
+		}
+
+		c := obs.Path[next]
+		if a.isSnake(c) || a.isObstacle(c) || a.isBoss(c) {
+			continue
+		}
+		obs.PathIndex = next
+		obs.Coord = c
+		obs.LastMove = now
+	}
+}
+
+func (a *Arena) isBoss(c Coord) bool {
+	for _, boss := range a.Bosses {
+		if boss.IsAlive && boss.IsOnPosition(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Game) drawObstacles() {
+	// rota dos moveis por baixo de tudo
+	for _, obs := range g.arena.Obstacles {
+		if obs.ObstacleType != OBSTACLE_MOVING {
+			continue
+		}
+		for _, c := range obs.Path {
+			g.r.SetCell(c.X, c.Y, '·', termbox.ColorDarkGray, termbox.ColorDefault)
+		}
+	}
+
+	for _, obs := range g.arena.Obstacles {
+		char := '█'
+		color := termbox.ColorMagenta
+		if obs.ObstacleType == OBSTACLE_MOVING {
+			char = '◆'
+			color = termbox.ColorYellow
+		}
+		if obs.IsTemporary {
+			color = color | termbox.AttrBold
+		}
+		g.r.SetCell(obs.X, obs.Y, char, color, termbox.ColorDefault)
+	}
+}
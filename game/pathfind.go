@@ -0,0 +1,128 @@
+package game
+
+// celulas que a busca de caminho dos estrangeiros pode expandir por tick,
+// somando todos eles. Passou disso, quem sobrou anda no greedy
+const PATH_BUDGET_PER_TICK = 3000
+
+// grade de ocupacao da arena montada uma vez por tick: paredes, obstaculos,
+// corpos dos jogadores (menos as cabecas, que sao alvo) e corpos dos estrangeiros
+func (a *Arena) buildPathGrid() {
+	size := a.Width * a.Height
+	if len(a.pathGrid) != size {
+		a.pathGrid = make([]bool, size)
+	}
+	for i := range a.pathGrid {
+		x, y := a.X+i%a.Width, a.Y+i/a.Width
+		a.pathGrid[i] = a.isWall(Coord{X: x, Y: y})
+	}
+
+	mark := func(c Coord) {
+		if i, ok := a.gridIndex(c); ok {
+			a.pathGrid[i] = true
+		}
+	}
+	for _, obs := range a.Obstacles {
+		mark(obs.Coord)
+	}
+	for _, p := range a.Players {
+		if p.Alive {
+			for _, seg := range p.Snake.Body[1:] {
+				mark(seg)
+			}
+		}
+	}
+	for _, boss := range a.Bosses {
+		if boss.IsAlive {
+			for _, seg := range boss.Body {
+				mark(seg)
+			}
+		}
+	}
+	a.pathBudget = PATH_BUDGET_PER_TICK
+}
+
+// atualiza a grade quando um estrangeiro anda no meio do tick
+func (a *Arena) markPathGrid(freed, taken Coord) {
+	if i, ok := a.gridIndex(freed); ok {
+		a.pathGrid[i] = a.isWall(freed) || a.isObstacle(freed)
+	}
+	if i, ok := a.gridIndex(taken); ok {
+		a.pathGrid[i] = true
+	}
+}
+
+func (a *Arena) gridIndex(c Coord) (int, bool) {
+	x, y := c.X-a.X, c.Y-a.Y
+	if x < 0 || y < 0 || x >= a.Width || y >= a.Height {
+		return 0, false
+	}
+	return y*a.Width + x, true
+}
+
+func (a *Arena) bossBlocked(c Coord) bool {
+	i, ok := a.gridIndex(c)
+	if !ok {
+		return true
+	}
+	return a.pathGrid[i]
+}
+
+// BFS do ponto de partida ate o alvo mais proximo, devolve o primeiro passo.
+// Falha se nao houver caminho ou se o orcamento do tick acabar
+func (a *Arena) findPath(from Coord, targets []Coord) (Coord, bool) {
+	start, ok := a.gridIndex(from)
+	if !ok || a.pathBudget <= 0 {
+		return Coord{}, false
+	}
+
+	isTarget := make(map[int]bool, len(targets))
+	for _, t := range targets {
+		if i, ok := a.gridIndex(t); ok {
+			isTarget[i] = true
+		}
+	}
+	if len(isTarget) == 0 {
+		return Coord{}, false
+	}
+
+	parent := make([]int, len(a.pathGrid))
+	for i := range parent {
+		parent[i] = -1
+	}
+	parent[start] = start
+	queue := []int{start}
+	dirs := []Coord{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+
+	for len(queue) > 0 {
+		if a.pathBudget <= 0 {
+			return Coord{}, false
+		}
+		a.pathBudget--
+
+		cur := queue[0]
+		queue = queue[1:]
+
+		if isTarget[cur] && cur != start {
+			// volta pelos pais ate o vizinho da partida
+			for parent[cur] != start {
+				cur = parent[cur]
+			}
+			return Coord{X: cur%a.Width + a.X - from.X, Y: cur/a.Width + a.Y - from.Y}, true
+		}
+
+		cx, cy := cur%a.Width, cur/a.Width
+		for _, d := range dirs {
+			nx, ny := cx+d.X, cy+d.Y
+			if nx < 0 || ny < 0 || nx >= a.Width || ny >= a.Height {
+				continue
+			}
+			next := ny*a.Width + nx
+			if parent[next] != -1 || (a.pathGrid[next] && !isTarget[next]) {
+				continue
+			}
+			parent[next] = cur
+			queue = append(queue, next)
+		}
+	}
+	return Coord{}, false
+}
@@ -0,0 +1,225 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// modos de partida
+const (
+	MODE_SOLO   = "solo"
+	MODE_VERSUS = "versus"
+	MODE_COOP   = "coop"
+	MODE_ONLINE = "online" // servidor: regras do versus para N jogadores
+)
+
+// limite de jogadores numa arena, um por posicao de largada
+const MAX_PLAYERS = 8
+
+// posicoes de largada: cabeca e direcao de cada jogador
+var playerSpawns = []struct {
+	Head, Dir Coord
+}{
+	{Coord{30, 12}, Coord{1, 0}},
+	{Coord{30, 18}, Coord{-1, 0}},
+	{Coord{30, 6}, Coord{1, 0}},
+	{Coord{30, 24}, Coord{-1, 0}},
+	{Coord{12, 9}, Coord{1, 0}},
+	{Coord{48, 15}, Coord{-1, 0}},
+	{Coord{12, 21}, Coord{1, 0}},
+	{Coord{48, 9}, Coord{-1, 0}},
+}
+
+// pontos para quem derruba o rival no versus
+const VERSUS_KILL_POINTS = 100
+
+// causas de morte gravadas no score
+const (
+	DEATH_WALL     = "parede"
+	DEATH_SELF     = "proprio_corpo"
+	DEATH_OBSTACLE = "obstaculo"
+	DEATH_PLAYER   = "outro_jogador" // bateu no corpo de um rival
+	DEATH_HEAD_ON  = "trombada"      // cabeca com cabeca
+	DEATH_QUIT     = "desistiu"
+	DEATH_ALIVE    = "vivo" // a partida acabou sem ele morrer
+)
+
+// Player e tudo que pertence a um jogador: cobra, pontos, combo e efeitos.
+// A arena embute o jogador da vez, entao a.Snake, a.Points, a.Shield...
+// sempre se referem a ele
+type Player struct {
+	Snake       *Snake
+	Points      int
+	ComboSystem *ComboSystem
+	Shield      bool
+	GhostUntil  time.Time
+	MagnetUntil time.Time
+	BonusActive bool
+	BonusType   string
+	bonusUntil  time.Time
+	Alive       bool
+
+	// estatisticas da partida, vao para o score
+	Ticks         int    // ticks jogados ate morrer ou a partida acabar
+	FoodsEaten    [3]int // por tipo: FOOD_NORMAL, FOOD_BONUS, FOOD_PENALTY
+	BossesKilled  int
+	MinionsKilled int
+	DeathCause    string
+}
+
+func newPlayer(snake *Snake) *Player {
+	return &Player{
+		Snake: snake,
+		ComboSystem: &ComboSystem{
+			ComboTimeout: 3 * time.Second,
+			MaxCombo:     0,
+		},
+		Alive: true,
+	}
+}
+
+// jogadores da partida, cada um na sua posicao de largada. O primeiro e a
+// cobra de sempre do modo solo
+func newPlayers(count int) []*Player {
+	if count < 1 {
+		count = 1
+	}
+	if count > MAX_PLAYERS {
+		count = MAX_PLAYERS
+	}
+	players := []*Player{newPlayer(newSnake())}
+	for _, spawn := range playerSpawns[1:count] {
+		body := make([]Coord, 3)
+		for i := range body {
+			body[i] = Coord{X: spawn.Head.X - spawn.Dir.X*i, Y: spawn.Head.Y - spawn.Dir.Y*i}
+		}
+		players = append(players, newPlayer(&Snake{Body: body, Dir: spawn.Dir}))
+	}
+	return players
+}
+
+// direcao ou saida de um jogador
+func (a *Arena) applyPlayerInput(p *Player, in Input) {
+	switch in {
+	case INPUT_UP:
+		p.Snake.ChangeDir(0, -1)
+	case INPUT_DOWN:
+		p.Snake.ChangeDir(0, 1)
+	case INPUT_LEFT:
+		p.Snake.ChangeDir(-1, 0)
+	case INPUT_RIGHT:
+		p.Snake.ChangeDir(1, 0)
+	case INPUT_QUIT:
+		if p.Alive {
+			p.Alive = false
+			p.DeathCause = DEATH_QUIT
+		}
+	}
+}
+
+// versus e online: as cobras batem uma na outra
+func (a *Arena) competitive() bool {
+	return a.Mode == MODE_VERSUS || a.Mode == MODE_ONLINE
+}
+
+// alguma cobra viva ocupa c
+func (a *Arena) isSnake(c Coord) bool {
+	for _, p := range a.Players {
+		if p.Alive && p.Snake.IsOnPosition(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// cabeca viva mais perto de from, usada por quem persegue "o jogador"
+func (a *Arena) nearestPlayerHead(from Coord) Coord {
+	best := a.Snake.Head()
+	bestDist := -1
+	for _, p := range a.Players {
+		if !p.Alive {
+			continue
+		}
+		head := p.Snake.Head()
+		dist := abs(head.X-from.X) + abs(head.Y-from.Y)
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = head, dist
+		}
+	}
+	return best
+}
+
+func (a *Arena) alivePlayers() int {
+	alive := 0
+	for _, p := range a.Players {
+		if p.Alive {
+			alive++
+		}
+	}
+	return alive
+}
+
+// a partida segue enquanto houver alguem vivo; no versus e online acaba
+// quando sobra um so
+func (a *Arena) matchOver() bool {
+	if a.competitive() && len(a.Players) > 1 {
+		return a.alivePlayers() <= 1
+	}
+	return a.alivePlayers() == 0
+}
+
+// versus: cabeca com cabeca a cobra maior vence (empate derruba as duas);
+// cabeca no corpo do rival derruba quem bateu e da pontos ao rival.
+// No cooperativo as cobras se atravessam
+func (a *Arena) resolvePlayerCollisions(prevHeads []Coord) {
+	if !a.competitive() {
+		return
+	}
+
+	type hit struct {
+		victim, killer int
+	}
+	var hits []hit
+	for i, p := range a.Players {
+		if !p.Alive {
+			continue
+		}
+		head := p.Snake.Head()
+		for j, q := range a.Players {
+			if i == j || !q.Alive {
+				continue
+			}
+			other := q.Snake.Head()
+			if head == other || (head == prevHeads[j] && other == prevHeads[i]) {
+				if len(p.Snake.Body) <= len(q.Snake.Body) {
+					hits = append(hits, hit{victim: i, killer: -1})
+				}
+				continue
+			}
+			if q.Snake.IsOnPosition(head) && !a.Now().Before(p.GhostUntil) {
+				hits = append(hits, hit{victim: i, killer: j})
+			}
+		}
+	}
+
+	for _, h := range hits {
+		p := a.Players[h.victim]
+		if !p.Alive {
+			continue
+		}
+		if p.Shield {
+			p.Shield = false
+			a.AddMessage(fmt.Sprintf("O escudo salvou o Jogador %d!", h.victim+1), 2*time.Second)
+			continue
+		}
+		p.Alive = false
+		p.DeathCause = DEATH_HEAD_ON
+		if h.killer >= 0 {
+			p.DeathCause = DEATH_PLAYER
+			a.Players[h.killer].Points += VERSUS_KILL_POINTS
+			a.AddMessage(fmt.Sprintf("Jogador %d derrubou o Jogador %d! +%d pts", h.killer+1, h.victim+1, VERSUS_KILL_POINTS), 3*time.Second)
+		} else {
+			a.AddMessage(fmt.Sprintf("Jogador %d perdeu na trombada!", h.victim+1), 3*time.Second)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// duracao dos efeitos temporarios
+const (
+	GHOST_DURATION  = 6 * time.Second
+	MAGNET_DURATION = 8 * time.Second
+	MAGNET_RADIUS   = 8
+)
+
+// chance por tick de aparecer um power-up, depois do intervalo minimo
+func (a *Arena) trySpawnPowerUp() {
+	if a.Level < 2 || len(a.PowerUps) >= 1+a.Level/5 || len(a.PowerUps) >= 3 {
+		return
+	}
+	if a.Now().Sub(a.lastPowerUpSpawn) < 15*time.Second || a.rng.Float64() > 0.02 {
+		return
+	}
+
+	for attempts := 0; attempts < 30; attempts++ {
+		x := a.rng.Intn(a.Width-4) + a.X + 2
+		y := a.rng.Intn(a.Height-4) + a.Y + 2
+		c := Coord{X: x, Y: y}
+
+		if a.isPositionValid(c) {
+			a.PowerUps = append(a.PowerUps, &PowerUp{
+				Coord:     c,
+				PowerType: a.rng.Intn(3),
+				SpawnTime: a.Now(),
+				Lifetime:  time.Duration(8+a.rng.Intn(5)) * time.Second,
+			})
+			a.lastPowerUpSpawn = a.Now()
+			return
+		}
+	}
+}
+
+func (a *Arena) collectPowerUps(head Coord) {
+	remaining := make([]*PowerUp, 0, len(a.PowerUps))
+	for _, p := range a.PowerUps {
+		if p.X != head.X || p.Y != head.Y {
+			remaining = append(remaining, p)
+			continue
+		}
+
+		switch p.PowerType {
+		case POWERUP_SHIELD:
+			a.Shield = true
+			a.AddMessage("ESCUDO! Absorve a proxima batida", 3*time.Second)
+		case POWERUP_GHOST:
+			a.GhostUntil = a.Now().Add(GHOST_DURATION)
+			a.AddMessage("FANTASMA! Atravesse obstaculos e seu corpo", 3*time.Second)
+		case POWERUP_MAGNET:
+			a.MagnetUntil = a.Now().Add(MAGNET_DURATION)
+			a.AddMessage("IMA! As frutas vem ate voce", 3*time.Second)
+		}
+	}
+	a.PowerUps = remaining
+}
+
+func (a *Arena) GhostActive() bool {
+	return a.Now().Before(a.GhostUntil)
+}
+
+func (a *Arena) MagnetActive() bool {
+	return a.Now().Before(a.MagnetUntil)
+}
+
+// ima: frutas proximas andam uma casa em direcao a cabeca
+func (a *Arena) pullFoods(head Coord) {
+	for _, food := range a.Foods {
+		dx := head.X - food.X
+		dy := head.Y - food.Y
+		if abs(dx)+abs(dy) > MAGNET_RADIUS || (dx == 0 && dy == 0) {
+			continue
+		}
+
+		step := Coord{X: food.X, Y: food.Y}
+		if abs(dx) > abs(dy) {
+			step.X += sign(dx)
+		} else {
+			step.Y += sign(dy)
+		}
+
+		if step != head && !a.isPositionValid(step) {
+			continue
+		}
+		food.Coord = step
+	}
+}
+
+// direcao perpendicular livre para a cobra escapar de uma parede
+func (a *Arena) escapeDir() Coord {
+	head := a.Snake.Head()
+	dirs := []Coord{{X: a.Snake.Dir.Y, Y: a.Snake.Dir.X}, {X: -a.Snake.Dir.Y, Y: -a.Snake.Dir.X}}
+	for _, d := range dirs {
+		next := Coord{X: head.X + d.X, Y: head.Y + d.Y}
+		if !a.isWall(next) && !a.isObstacle(next) && !a.isSnake(next) {
+			return d
+		}
+	}
+	return a.Snake.Dir
+}
+
+func (g *Game) drawPowerUps() {
+	for _, p := range g.arena.PowerUps {
+		var char rune
+		var color termbox.Attribute
+
+		switch p.PowerType {
+		case POWERUP_SHIELD:
+			char = '⊕'
+			color = termbox.ColorCyan | termbox.AttrBold
+		case POWERUP_GHOST:
+			char = '◌'
+			color = termbox.ColorWhite | termbox.AttrBold
+		case POWERUP_MAGNET:
+			char = '∩'
+			color = termbox.ColorBlue | termbox.AttrBold
+		}
+
+		// pisca quando esta para sumir
+		timeLeft := p.Lifetime - g.arena.Now().Sub(p.SpawnTime)
+		if timeLeft < 2*time.Second && (time.Now().UnixNano()/250000000)%2 == 0 {
+			continue
+		}
+
+		g.r.SetCell(p.X, p.Y, char, color, termbox.ColorDefault)
+	}
+}
+
+// segundos restantes arredondados para cima
+func secondsLeft(until, now time.Time) int {
+	return int((until.Sub(now) + time.Second - 1) / time.Second)
+}
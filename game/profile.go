@@ -0,0 +1,154 @@
+package game
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tamanho maximo do nome do jogador
+const MAX_NAME_LEN = 16
+
+// Profile e a identidade de um jogador: o id nao muda, o nome pode ser
+// trocado sem perder o historico (os scores guardam o id)
+type Profile struct {
+	ID        string    `bson:"_id" json:"id"`
+	Nome      string    `bson:"nome" json:"nome"`
+	Criado    time.Time `bson:"criado" json:"criado"`
+	UltimoUso time.Time `bson:"ultimo_uso" json:"ultimo_uso"`
+}
+
+// perfis desta maquina, em profiles.json no diretorio de dados
+type ProfileBook struct {
+	Atual  string     `json:"atual"`
+	Perfis []*Profile `json:"perfis"`
+
+	path string
+}
+
+func profilesPath() string {
+	return filepath.Join(dataDir(), "profiles.json")
+}
+
+// le os perfis; arquivo que nao existe e so um livro vazio
+func LoadProfiles(path string) (*ProfileBook, error) {
+	b := &ProfileBook{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return b, err
+	}
+	if err := json.Unmarshal(data, b); err != nil {
+		return b, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+func (b *ProfileBook) save() error {
+	return writeJSONFile(b.path, b)
+}
+
+// perfil em uso, nil na primeira vez
+func (b *ProfileBook) Current() *Profile {
+	for _, p := range b.Perfis {
+		if p.ID == b.Atual {
+			return p
+		}
+	}
+	return nil
+}
+
+// perfil com esse nome (sem diferenciar maiusculas), nil se nao tiver.
+// Add e Rename nao deixam dois perfis com o mesmo nome
+func (b *ProfileBook) Find(name string) *Profile {
+	for _, p := range b.Perfis {
+		if strings.EqualFold(p.Nome, strings.TrimSpace(name)) {
+			return p
+		}
+	}
+	return nil
+}
+
+// cria um perfil e passa a usar ele
+func (b *ProfileBook) Add(name string) (*Profile, error) {
+	name, err := b.freeName(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	p := &Profile{ID: newUUID(), Nome: name, Criado: now, UltimoUso: now}
+	b.Perfis = append(b.Perfis, p)
+	b.Atual = p.ID
+	return p, b.save()
+}
+
+func (b *ProfileBook) Rename(p *Profile, name string) error {
+	name, err := b.freeName(name, p)
+	if err != nil {
+		return err
+	}
+	p.Nome = name
+	return b.save()
+}
+
+func (b *ProfileBook) Switch(p *Profile) error {
+	b.Atual = p.ID
+	p.UltimoUso = time.Now()
+	return b.save()
+}
+
+// nome valido e sem outro perfil (fora self) com ele
+func (b *ProfileBook) freeName(name string, self *Profile) (string, error) {
+	name, err := cleanProfileName(name)
+	if err != nil {
+		return "", err
+	}
+	if other := b.Find(name); other != nil && other != self {
+		return "", fmt.Errorf("ja existe um perfil chamado %s", other.Nome)
+	}
+	return name, nil
+}
+
+func cleanProfileName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("o nome nao pode ficar vazio")
+	}
+	if len([]rune(name)) > MAX_NAME_LEN {
+		return "", fmt.Errorf("o nome pode ter ate %d letras", MAX_NAME_LEN)
+	}
+	return name, nil
+}
+
+// UUID versao 4 (aleatorio)
+func newUUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// ProfileStore e opcional, como o ScoreWatcher: stores com banco (o
+// MongoStore, na colecao players) guardam os perfis alem do arquivo local
+type ProfileStore interface {
+	SaveProfile(ctx context.Context, p Profile) error
+}
+
+// grava o perfil no store, se ele souber guardar perfis
+func saveProfile(ctx context.Context, store ScoreStore, p Profile) error {
+	if ps, ok := store.(ProfileStore); ok {
+		return ps.SaveProfile(ctx, p)
+	}
+	return nil
+}
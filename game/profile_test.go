@@ -0,0 +1,41 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// dois perfis nao podem ter o mesmo nome, nem trocando maiusculas
+func TestProfileDuplicateName(t *testing.T) {
+	b, err := LoadProfiles(filepath.Join(t.TempDir(), "profiles.json"))
+	if err != nil {
+		t.Fatalf("LoadProfiles: %v", err)
+	}
+	ana, err := b.Add("Ana")
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	bia, err := b.Add("Bia")
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	if _, err := b.Add(" ana "); err == nil {
+		t.Error("Add aceitou um nome repetido")
+	}
+	if err := b.Rename(bia, "ANA"); err == nil {
+		t.Error("Rename aceitou o nome de outro perfil")
+	}
+	if bia.Nome != "Bia" {
+		t.Errorf("nome depois do Rename recusado: %q", bia.Nome)
+	}
+	if err := b.Rename(ana, "ANA"); err != nil {
+		t.Errorf("Rename do proprio nome: %v", err)
+	}
+	if got := b.Find("ana"); got != ana {
+		t.Errorf("Find: %v, esperado %v", got, ana)
+	}
+	if len(b.Perfis) != 2 {
+		t.Errorf("perfis: %d, esperado 2", len(b.Perfis))
+	}
+}
@@ -0,0 +1,185 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/nsf/termbox-go"
+)
+
+// passa a jogar com o perfil p e atualiza a colecao players
+func (g *Game) useProfile(p *Profile) {
+	g.userID = p.Nome
+	g.profileID = p.ID
+	g.storeProfile(p)
+}
+
+// grava o perfil no store em segundo plano, para o menu nao esperar o banco
+func (g *Game) storeProfile(p *Profile) {
+	profile := *p
+	go func() {
+		if err := saveProfile(context.Background(), g.store, profile); err != nil {
+			log.Printf("Erro ao salvar perfil %s: %v", profile.Nome, err)
+		}
+	}()
+}
+
+// primeira vez nesta maquina: pede o nome antes do menu. False se o
+// jogador desistiu (ESC)
+func (g *Game) ensureProfile() bool {
+	if g.profiles == nil {
+		return true
+	}
+	if p := g.profiles.Current(); p != nil {
+		g.useProfile(p)
+		return true
+	}
+
+	msg := "Como voce quer aparecer no ranking?"
+	for {
+		name, ok := g.readText("BEM-VINDO AO SNAKE GO", msg, "")
+		if !ok {
+			return false
+		}
+		p, err := g.profiles.Add(name)
+		if err != nil {
+			msg = err.Error()
+			continue
+		}
+		g.useProfile(p)
+		return true
+	}
+}
+
+// perfis desta maquina: ENTER troca, R renomeia, N cria outro
+func (g *Game) showProfiles() {
+	selected := 0
+	for i, p := range g.profiles.Perfis {
+		if p.ID == g.profileID {
+			selected = i
+		}
+	}
+	status := ""
+
+	for {
+		g.r.Clear()
+		width, height := g.r.Size()
+
+		title := "PERFIS"
+		g.drawText((width-len(title))/2, 2, termbox.ColorGreen|termbox.AttrBold, termbox.ColorDefault, title)
+
+		for i, p := range g.profiles.Perfis {
+			line := fmt.Sprintf("%-16s  desde %s", p.Nome, p.Criado.Format("02/01/2006"))
+			if p.ID == g.profileID {
+				line += "  (atual)"
+			}
+			x, y := (width-40)/2, 5+i
+
+			color := termbox.ColorWhite
+			if i == selected {
+				color = termbox.ColorYellow | termbox.AttrBold
+				g.drawText(x-2, y, color, termbox.ColorDefault, ">")
+			}
+			g.drawText(x, y, color, termbox.ColorDefault, line)
+		}
+
+		if status != "" {
+			g.drawText((width-len([]rune(status)))/2, height-5, termbox.ColorCyan, termbox.ColorDefault, status)
+		}
+		hint := "ENTER usa • R renomeia • N novo perfil • ESC volta"
+		g.drawText((width-len([]rune(hint)))/2, height-3, termbox.ColorGreen, termbox.ColorDefault, hint)
+		g.r.Flush()
+
+		ev := <-g.events
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		switch {
+		case ev.Key == termbox.KeyArrowUp:
+			selected = (selected - 1 + len(g.profiles.Perfis)) % len(g.profiles.Perfis)
+		case ev.Key == termbox.KeyArrowDown:
+			selected = (selected + 1) % len(g.profiles.Perfis)
+		case ev.Key == termbox.KeyEnter:
+			p := g.profiles.Perfis[selected]
+			if err := g.profiles.Switch(p); err != nil {
+				log.Printf("Erro ao gravar perfis: %v", err)
+			}
+			g.useProfile(p)
+			status = "Jogando como " + p.Nome
+		case ev.Ch == 'r' || ev.Ch == 'R':
+			p := g.profiles.Perfis[selected]
+			name, ok := g.readText("RENOMEAR PERFIL", "Novo nome para "+p.Nome, p.Nome)
+			if !ok {
+				continue
+			}
+			if err := g.profiles.Rename(p, name); err != nil {
+				status = err.Error()
+				continue
+			}
+			// players fica com o nome novo mesmo quando o perfil nao e o atual
+			if p.ID == g.profileID {
+				g.useProfile(p)
+			} else {
+				g.storeProfile(p)
+			}
+			status = "Perfil renomeado para " + p.Nome
+		case ev.Ch == 'n' || ev.Ch == 'N':
+			name, ok := g.readText("NOVO PERFIL", "Nome do novo jogador", "")
+			if !ok {
+				continue
+			}
+			p, err := g.profiles.Add(name)
+			if err != nil {
+				status = err.Error()
+				continue
+			}
+			g.useProfile(p)
+			selected = len(g.profiles.Perfis) - 1
+			status = "Jogando como " + p.Nome
+		case ev.Key == termbox.KeyEsc:
+			return
+		}
+	}
+}
+
+// campo de texto de uma linha; ENTER confirma, ESC cancela
+func (g *Game) readText(title, prompt, initial string) (string, bool) {
+	text := []rune(initial)
+	for {
+		g.r.Clear()
+		width, height := g.r.Size()
+
+		g.drawText((width-len([]rune(title)))/2, height/2-4, termbox.ColorGreen|termbox.AttrBold, termbox.ColorDefault, title)
+		g.drawText((width-len([]rune(prompt)))/2, height/2-2, termbox.ColorWhite, termbox.ColorDefault, prompt)
+
+		x := (width - MAX_NAME_LEN - 2) / 2
+		g.drawText(x, height/2, termbox.ColorDarkGray, termbox.ColorDefault, "["+fmt.Sprintf("%-*s", MAX_NAME_LEN, "")+"]")
+		g.drawText(x+1, height/2, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault, string(text)+"_")
+
+		hint := "ENTER confirma • ESC cancela"
+		g.drawText((width-len([]rune(hint)))/2, height/2+3, termbox.ColorDarkGray, termbox.ColorDefault, hint)
+		g.r.Flush()
+
+		ev := <-g.events
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		switch {
+		case ev.Key == termbox.KeyEnter:
+			return string(text), true
+		case ev.Key == termbox.KeyEsc:
+			return "", false
+		case ev.Key == termbox.KeyBackspace || ev.Key == termbox.KeyBackspace2:
+			if len(text) > 0 {
+				text = text[:len(text)-1]
+			}
+		case ev.Key == termbox.KeySpace:
+			if len(text) < MAX_NAME_LEN {
+				text = append(text, ' ')
+			}
+		case ev.Ch >= ' ' && len(text) < MAX_NAME_LEN:
+			text = append(text, ev.Ch)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// intervalo entre tentativas de reenviar a fila para o replica set
+const QUEUE_RETRY_INTERVAL = 10 * time.Second
+
+// id gerado no cliente, e o _id do documento no MongoDB. Reenviar o mesmo
+// score duas vezes cai na chave duplicada, entao a sincronizacao e idempotente
+func newScoreID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// fila de escrita antecipada: um score JSON por linha, gravado com fsync
+// antes de o Save retornar
+type scoreSpool struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (s *scoreSpool) Append(score Score) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(score)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func (s *scoreSpool) Pending() ([]Score, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+func (s *scoreSpool) read() ([]Score, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var scores []Score
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var score Score
+		// linha cortada por um crash durante o Append: ignora
+		if err := json.Unmarshal(scanner.Bytes(), &score); err != nil {
+			continue
+		}
+		scores = append(scores, score)
+	}
+	return scores, scanner.Err()
+}
+
+// tira da fila os scores ja enviados
+func (s *scoreSpool) Remove(sent map[string]bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scores, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, score := range scores {
+		if sent[score.ID] {
+			continue
+		}
+		line, err := json.Marshal(score)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+
+	if buf.Len() == 0 {
+		return os.Remove(s.path)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// QueuedStore grava no store remoto e, quando ele falha, guarda o score na
+// fila local. Uma goroutine reenvia a fila ate o remoto voltar, inclusive
+// scores que sobraram de uma execucao anterior do jogo. Score que o remoto
+// recusa (ErrScoreRejected) nunca vai passar, entao nao entra na fila; os
+// que ja estavam nela vao para um arquivo a parte, para nao travar o resto
+type QueuedStore struct {
+	remote   ScoreStore
+	spool    *scoreSpool
+	rejected *scoreSpool
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func NewQueuedStore(remote ScoreStore, spoolPath string) *QueuedStore {
+	q := &QueuedStore{
+		remote:   remote,
+		spool:    &scoreSpool{path: spoolPath},
+		rejected: &scoreSpool{path: strings.TrimSuffix(spoolPath, ".jsonl") + "_rejected.jsonl"},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go q.syncLoop()
+	return q
+}
+
+func (q *QueuedStore) Save(s Score) error {
+	if s.ID == "" {
+		s.ID = newScoreID()
+	}
+
+	err := q.remote.Save(s)
+	if err == nil || errors.Is(err, ErrScoreRejected) {
+		return err
+	}
+
+	log.Printf("Erro ao salvar score no MongoDB: %v — guardado na fila local", err)
+	return q.spool.Append(s)
+}
+
+// ranking do remoto; sem conexao mostra ao menos o que esta na fila
+func (q *QueuedStore) Top(query ScoreQuery) ([]Score, error) {
+	scores, err := q.remote.Top(query)
+	if err == nil {
+		return scores, nil
+	}
+
+	pending, spoolErr := q.spool.Pending()
+	if spoolErr != nil || len(pending) == 0 {
+		return nil, err
+	}
+	log.Printf("Erro ao buscar ranking: %v — mostrando scores da fila local", err)
+	return rankScores(pending, query), nil
+}
+
+// scores novos vem do remoto; os que estao na fila aparecem quando forem
+// enviados
+func (q *QueuedStore) WatchScores(ctx context.Context) (<-chan Score, error) {
+	return watchScores(ctx, q.remote)
+}
+
+// perfis nao entram na fila: o jogo grava o perfil de novo a cada
+// abertura, entao o remoto fica em dia quando voltar
+func (q *QueuedStore) SaveProfile(ctx context.Context, p Profile) error {
+	return saveProfile(ctx, q.remote, p)
+}
+
+func (q *QueuedStore) PlayerRank(query ScoreQuery, name, id string) (int, error) {
+	return playerRank(q.remote, query, name, id)
+}
+
+// estatisticas do remoto; scores na fila entram quando forem enviados
+func (q *QueuedStore) PlayerStats(name, id string) (PlayerStats, error) {
+	return playerStats(q.remote, name, id)
+}
+
+// quantos scores aguardam envio
+func (q *QueuedStore) Pending() int {
+	pending, _ := q.spool.Pending()
+	return len(pending)
+}
+
+// tenta enviar toda a fila agora
+func (q *QueuedStore) Flush() (int, error) {
+	pending, err := q.spool.Pending()
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	// done inclui os recusados, que tambem saem da fila
+	done := make(map[string]bool)
+	sent := 0
+	var sendErr error
+	for _, score := range pending {
+		err := q.remote.Save(score)
+		if errors.Is(err, ErrScoreRejected) {
+			log.Printf("Score %s de %s recusado pelo MongoDB: %v — movido para %s", score.ID, score.Nome, err, q.rejected.path)
+			if err := q.rejected.Append(score); err != nil {
+				sendErr = err
+				break
+			}
+			done[score.ID] = true
+			continue
+		}
+		if sendErr = err; sendErr != nil {
+			break
+		}
+		done[score.ID] = true
+		sent++
+	}
+
+	if len(done) > 0 {
+		if err := q.spool.Remove(done); err != nil {
+			return sent, err
+		}
+	}
+	if sent > 0 {
+		log.Printf("Fila local sincronizada: %d score(s) enviados ao MongoDB", sent)
+	}
+	return sent, sendErr
+}
+
+func (q *QueuedStore) syncLoop() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(QUEUE_RETRY_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		q.Flush()
+		select {
+		case <-ticker.C:
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+func (q *QueuedStore) Close() error {
+	q.once.Do(func() {
+		close(q.stop)
+		<-q.done
+		q.Flush()
+	})
+	if c, ok := q.remote.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// remoto que recusa os scores de um nome, como o validador do MongoDB
+type rejectingStore struct {
+	*MemoryStore
+	reject string
+}
+
+func (r *rejectingStore) Save(s Score) error {
+	if s.Nome == r.reject {
+		return fmt.Errorf("%w: validador", ErrScoreRejected)
+	}
+	return r.MemoryStore.Save(s)
+}
+
+// score recusado volta para quem chamou em vez de ir para a fila
+func TestQueuedStoreRejectedNotSpooled(t *testing.T) {
+	remote := &rejectingStore{MemoryStore: NewMemoryStore(), reject: "ruim"}
+	q := NewQueuedStore(remote, filepath.Join(t.TempDir(), "score_queue.jsonl"))
+	defer q.Close()
+
+	if err := q.Save(Score{Nome: "ruim", Pontos: 10}); !errors.Is(err, ErrScoreRejected) {
+		t.Fatalf("Save: %v, esperado ErrScoreRejected", err)
+	}
+	if n := q.Pending(); n != 0 {
+		t.Errorf("%d scores na fila, esperado nenhum", n)
+	}
+}
+
+// um score recusado no meio da fila sai dela e nao segura os outros
+func TestQueuedStoreFlushSkipsRejected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "score_queue.jsonl")
+	spool := &scoreSpool{path: path}
+	spool.Append(Score{ID: "a", Nome: "ana", Pontos: 10})
+	spool.Append(Score{ID: "b", Nome: "ruim", Pontos: 20})
+	spool.Append(Score{ID: "c", Nome: "bia", Pontos: 30})
+
+	remote := &rejectingStore{MemoryStore: NewMemoryStore(), reject: "ruim"}
+	q := NewQueuedStore(remote, path)
+	q.Close() // espera o reenvio
+
+	if n := q.Pending(); n != 0 {
+		t.Errorf("%d scores na fila, esperado nenhum", n)
+	}
+	if scores, _ := remote.Top(ScoreQuery{IncludeCheated: true}); len(scores) != 2 {
+		t.Errorf("enviados: %d, esperado 2", len(scores))
+	}
+	rejected, _ := q.rejected.Pending()
+	if len(rejected) != 1 || rejected[0].ID != "b" {
+		t.Errorf("recusados: %v", rejected)
+	}
+}
@@ -0,0 +1,146 @@
+package game
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Renderer e o destino de todo desenho do jogo e a fonte dos eventos de
+// teclado. As cores seguem os atributos do termbox em todos os backends
+type Renderer interface {
+	Init() error
+	Close()
+	Size() (width, height int)
+	Clear()
+	SetCell(x, y int, ch rune, fg, bg termbox.Attribute)
+	Flush() error
+	PollEvent() termbox.Event
+}
+
+// backend padrao, desenha direto no terminal via termbox
+type termboxRenderer struct{}
+
+func NewTermboxRenderer() Renderer {
+	return termboxRenderer{}
+}
+
+func (termboxRenderer) Init() error {
+	if err := termbox.Init(); err != nil {
+		return err
+	}
+	termbox.SetInputMode(termbox.InputEsc)
+	termbox.HideCursor()
+	return nil
+}
+
+func (termboxRenderer) Close() { termbox.Close() }
+
+func (termboxRenderer) Size() (int, int) { return termbox.Size() }
+
+func (termboxRenderer) Clear() {
+	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+}
+
+func (termboxRenderer) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
+	termbox.SetCell(x, y, ch, fg, bg)
+}
+
+func (termboxRenderer) Flush() error { return termbox.Flush() }
+
+func (termboxRenderer) PollEvent() termbox.Event { return termbox.PollEvent() }
+
+// RecordingRenderer nao desenha em lugar nenhum: guarda a ultima tela
+// enviada em memoria para ser inspecionada, e le eventos do canal Events
+type RecordingRenderer struct {
+	Events chan termbox.Event
+	Frames int
+
+	mu            sync.Mutex
+	width, height int
+	back, front   []termbox.Cell
+}
+
+func NewRecordingRenderer(width, height int) *RecordingRenderer {
+	return &RecordingRenderer{
+		Events: make(chan termbox.Event, 64),
+		width:  width,
+		height: height,
+		back:   make([]termbox.Cell, width*height),
+		front:  make([]termbox.Cell, width*height),
+	}
+}
+
+func (r *RecordingRenderer) Init() error { return nil }
+
+func (r *RecordingRenderer) Close() {}
+
+func (r *RecordingRenderer) Size() (int, int) { return r.width, r.height }
+
+func (r *RecordingRenderer) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.back {
+		r.back[i] = termbox.Cell{Ch: ' '}
+	}
+}
+
+func (r *RecordingRenderer) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
+	if x < 0 || y < 0 || x >= r.width || y >= r.height {
+		return
+	}
+	r.mu.Lock()
+	r.back[y*r.width+x] = termbox.Cell{Ch: ch, Fg: fg, Bg: bg}
+	r.mu.Unlock()
+}
+
+func (r *RecordingRenderer) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copy(r.front, r.back)
+	r.Frames++
+	return nil
+}
+
+// bloqueia ate alguem mandar um evento, como o termbox faz
+func (r *RecordingRenderer) PollEvent() termbox.Event {
+	return <-r.Events
+}
+
+// linha y da ultima tela enviada, sem espacos a direita
+func (r *RecordingRenderer) Line(y int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if y < 0 || y >= r.height {
+		return ""
+	}
+	var sb strings.Builder
+	for _, c := range r.front[y*r.width : (y+1)*r.width] {
+		if c.Ch == 0 {
+			sb.WriteRune(' ')
+		} else {
+			sb.WriteRune(c.Ch)
+		}
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+// ultima tela enviada, uma linha por linha do terminal
+func (r *RecordingRenderer) Screen() string {
+	lines := make([]string, r.height)
+	for y := range lines {
+		lines[y] = r.Line(y)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// verifica se o texto aparece em alguma linha da ultima tela
+func (r *RecordingRenderer) Contains(text string) bool {
+	for y := 0; y < r.height; y++ {
+		if strings.Contains(r.Line(y), text) {
+			return true
+		}
+	}
+	return false
+}
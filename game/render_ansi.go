@@ -0,0 +1,279 @@
+package game
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/nsf/termbox-go"
+	"golang.org/x/term"
+)
+
+// ANSIRenderer desenha com sequencias de escape ANSI puras, sem depender do
+// terminfo do termbox. Serve para terminais onde o termbox se perde e para
+// qualquer par leitor/escritor (ex: um canal SSH)
+type ANSIRenderer struct {
+	in  io.Reader
+	out io.Writer
+
+	mu            sync.Mutex
+	width, height int
+	back, front   []termbox.Cell
+	restore       func()
+	events        chan termbox.Event
+	closed        chan struct{}
+	closeOnce     sync.Once
+}
+
+// width/height zerados usam o tamanho do terminal de saida (ou 80x24)
+func NewANSIRenderer(in io.Reader, out io.Writer, width, height int) *ANSIRenderer {
+	return &ANSIRenderer{
+		in:     in,
+		out:    out,
+		width:  width,
+		height: height,
+		events: make(chan termbox.Event, 32),
+		closed: make(chan struct{}),
+	}
+}
+
+func (r *ANSIRenderer) Init() error {
+	if f, ok := r.in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		state, err := term.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return err
+		}
+		r.restore = func() { term.Restore(int(f.Fd()), state) }
+	}
+
+	if r.width == 0 || r.height == 0 {
+		r.width, r.height = 80, 24
+		if f, ok := r.out.(*os.File); ok {
+			if w, h, err := term.GetSize(int(f.Fd())); err == nil {
+				r.width, r.height = w, h
+			}
+		}
+	}
+	r.allocate()
+
+	// tela alternativa, cursor escondido e tela limpa
+	if _, err := io.WriteString(r.out, "\x1b[?1049h\x1b[?25l\x1b[0m\x1b[2J"); err != nil {
+		return err
+	}
+
+	go r.readInput()
+	return nil
+}
+
+func (r *ANSIRenderer) Close() {
+	r.closeOnce.Do(func() {
+		io.WriteString(r.out, "\x1b[0m\x1b[?25h\x1b[?1049l")
+		if r.restore != nil {
+			r.restore()
+		}
+		close(r.closed)
+	})
+}
+
+func (r *ANSIRenderer) allocate() {
+	r.back = make([]termbox.Cell, r.width*r.height)
+	r.front = make([]termbox.Cell, r.width*r.height)
+	for i := range r.back {
+		r.back[i] = termbox.Cell{Ch: ' '}
+	}
+}
+
+// muda o tamanho da tela e forca o redesenho completo no proximo Flush
+func (r *ANSIRenderer) Resize(width, height int) {
+	r.mu.Lock()
+	r.width, r.height = width, height
+	r.allocate()
+	r.mu.Unlock()
+
+	io.WriteString(r.out, "\x1b[2J")
+	select {
+	case r.events <- termbox.Event{Type: termbox.EventResize, Width: width, Height: height}:
+	default:
+	}
+}
+
+func (r *ANSIRenderer) Size() (int, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.width, r.height
+}
+
+func (r *ANSIRenderer) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.back {
+		r.back[i] = termbox.Cell{Ch: ' '}
+	}
+}
+
+func (r *ANSIRenderer) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if x < 0 || y < 0 || x >= r.width || y >= r.height {
+		return
+	}
+	r.back[y*r.width+x] = termbox.Cell{Ch: ch, Fg: fg, Bg: bg}
+}
+
+// envia so as celulas que mudaram desde o ultimo Flush
+func (r *ANSIRenderer) Flush() error {
+	r.mu.Lock()
+	var buf bytes.Buffer
+	lastX, lastY := -1, -1
+	var lastFg, lastBg termbox.Attribute = 0xFFFF, 0xFFFF
+
+	for i, cell := range r.back {
+		if cell == r.front[i] {
+			continue
+		}
+		x, y := i%r.width, i/r.width
+		if x != lastX+1 || y != lastY {
+			fmt.Fprintf(&buf, "\x1b[%d;%dH", y+1, x+1)
+		}
+		if cell.Fg != lastFg || cell.Bg != lastBg {
+			buf.WriteString(ansiSGR(cell.Fg, cell.Bg))
+			lastFg, lastBg = cell.Fg, cell.Bg
+		}
+		buf.WriteRune(cell.Ch)
+		lastX, lastY = x, y
+		r.front[i] = cell
+	}
+	r.mu.Unlock()
+
+	if buf.Len() == 0 {
+		return nil
+	}
+	buf.WriteString("\x1b[0m")
+	_, err := r.out.Write(buf.Bytes())
+	return err
+}
+
+func (r *ANSIRenderer) PollEvent() termbox.Event {
+	select {
+	case ev := <-r.events:
+		return ev
+	case <-r.closed:
+		return termbox.Event{Type: termbox.EventInterrupt}
+	}
+}
+
+func (r *ANSIRenderer) readInput() {
+	buf := make([]byte, 64)
+	for {
+		n, err := r.in.Read(buf)
+		if n > 0 {
+			for _, ev := range parseANSIInput(buf[:n]) {
+				select {
+				case r.events <- ev:
+				case <-r.closed:
+					return
+				}
+			}
+		}
+		if err != nil {
+			select {
+			case r.events <- termbox.Event{Type: termbox.EventError, Err: err}:
+			case <-r.closed:
+			}
+			return
+		}
+	}
+}
+
+// converte os bytes lidos do terminal em eventos no formato do termbox.
+// Terminais mandam cada sequencia de escape inteira numa unica escrita,
+// entao um ESC sozinho no fim do bloco e a tecla ESC
+func parseANSIInput(data []byte) []termbox.Event {
+	var events []termbox.Event
+	key := func(k termbox.Key) {
+		events = append(events, termbox.Event{Type: termbox.EventKey, Key: k})
+	}
+
+	for len(data) > 0 {
+		if data[0] == 0x1b {
+			if len(data) >= 3 && (data[1] == '[' || data[1] == 'O') {
+				switch data[2] {
+				case 'A':
+					key(termbox.KeyArrowUp)
+				case 'B':
+					key(termbox.KeyArrowDown)
+				case 'C':
+					key(termbox.KeyArrowRight)
+				case 'D':
+					key(termbox.KeyArrowLeft)
+				}
+				data = data[3:]
+				continue
+			}
+			key(termbox.KeyEsc)
+			data = data[1:]
+			continue
+		}
+
+		switch data[0] {
+		case '\r', '\n':
+			key(termbox.KeyEnter)
+		case 0x7f:
+			key(termbox.KeyBackspace2)
+		case 0x08:
+			key(termbox.KeyBackspace)
+		case 0x03:
+			key(termbox.KeyCtrlC)
+		case ' ':
+			key(termbox.KeySpace)
+		default:
+			ch, size := utf8.DecodeRune(data)
+			events = append(events, termbox.Event{Type: termbox.EventKey, Ch: ch})
+			data = data[size:]
+			continue
+		}
+		data = data[1:]
+	}
+	return events
+}
+
+// cores e atributos do termbox em codigos SGR
+func ansiSGR(fg, bg termbox.Attribute) string {
+	codes := "\x1b[0"
+	if fg&termbox.AttrBold != 0 {
+		codes += ";1"
+	}
+	if fg&termbox.AttrUnderline != 0 {
+		codes += ";4"
+	}
+	if fg&termbox.AttrBlink != 0 {
+		codes += ";5"
+	}
+	if fg&termbox.AttrReverse != 0 {
+		codes += ";7"
+	}
+	if c := ansiColor(fg, 30); c != "" {
+		codes += ";" + c
+	}
+	if c := ansiColor(bg, 40); c != "" {
+		codes += ";" + c
+	}
+	return codes + "m"
+}
+
+func ansiColor(attr termbox.Attribute, base int) string {
+	color := attr & 0x1FF
+	switch {
+	case color == termbox.ColorDefault:
+		return ""
+	case color <= termbox.ColorWhite:
+		return fmt.Sprint(base + int(color-termbox.ColorBlack))
+	case color <= termbox.ColorLightGray:
+		// cores claras: 90-97 na frente, 100-107 no fundo
+		return fmt.Sprint(base + 60 + int(color-termbox.ColorDarkGray))
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func newTestGame(r Renderer) *Game {
+	g, _ := NewGame(Options{Renderer: r, Store: NewMemoryStore(), Name: "teste"})
+	return g
+}
+
+// o HUD da partida solo sai na tela gravada, nas linhas acima da arena
+func TestDrawHUD(t *testing.T) {
+	r := NewRecordingRenderer(80, 40)
+	g := newTestGame(r)
+	g.score = 120
+	g.drawGame()
+
+	if r.Frames != 1 {
+		t.Fatalf("frames: %d, esperado 1", r.Frames)
+	}
+	top := r.Line(g.arena.Y - 3)
+	if !strings.Contains(top, "Score: 120") {
+		t.Errorf("linha do score: %q", top)
+	}
+	stats := r.Line(g.arena.Y - 2)
+	for _, want := range []string{"Nivel: 1", "Combo: x1", "Tamanho: 3"} {
+		if !strings.Contains(stats, want) {
+			t.Errorf("%q fora da linha do HUD: %q", want, stats)
+		}
+	}
+	if !r.Contains("ESC sair") {
+		t.Errorf("controles fora da tela:\n%s", r.Screen())
+	}
+}
+
+// espectadores e a vida do boss ficam em linhas diferentes
+func TestDrawHUDSpectatorsAndBoss(t *testing.T) {
+	r := NewRecordingRenderer(80, 40)
+	g := newTestGame(r)
+	g.arena.Bosses = append(g.arena.Bosses, newBoss(g.arena))
+	g.spectators = 2
+	g.drawGame()
+
+	boss := r.Line(g.arena.Y + g.arena.Height + 2)
+	if !strings.Contains(boss, g.arena.Bosses[0].Species().Name) || strings.Contains(boss, "Assistindo") {
+		t.Errorf("linha da vida do boss: %q", boss)
+	}
+	if bottom := r.Line(g.arena.Y + g.arena.Height + 3); !strings.Contains(bottom, "Assistindo: 2") {
+		t.Errorf("linha dos espectadores: %q", bottom)
+	}
+}
+
+// config do MongoDB invalida volta como erro, sem chegar a abrir a tela
+func TestNewGameInvalidConfig(t *testing.T) {
+	mongoConfigEnv(t, `{"write_concern": "todos"}`)
+	r := NewRecordingRenderer(80, 40)
+	if _, err := NewGame(Options{Renderer: r, Name: "teste"}); err == nil {
+		t.Fatal("NewGame aceitou a config invalida")
+	}
+	if r.Frames != 0 {
+		t.Errorf("%d frames desenhados antes do erro", r.Frames)
+	}
+}
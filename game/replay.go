@@ -0,0 +1,152 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// versao do formato de replay, muda quando a simulacao muda de forma que
+// replays antigos deixariam de reproduzir a mesma partida
+const REPLAY_VERSION = 7
+
+// entradas aplicadas antes do tick Tick
+type ReplayFrame struct {
+	Tick   int     `json:"t" bson:"t"`
+	Inputs []Input `json:"i" bson:"i"`
+}
+
+// Replay reproduz uma partida inteira: a simulacao e deterministica, entao
+// seed + entradas por tick bastam para refazer tudo
+type Replay struct {
+	Version   int           `json:"versao" bson:"versao"`
+	Mode      string        `json:"modo,omitempty" bson:"modo,omitempty"`
+	Seed      int64         `json:"seed" bson:"seed"`
+	Width     int           `json:"largura" bson:"largura"`
+	Height    int           `json:"altura" bson:"altura"`
+	Ticks     int           `json:"ticks" bson:"ticks"`
+	Frames    []ReplayFrame `json:"frames" bson:"frames"`
+	Jogador   string        `json:"jogador" bson:"jogador"`
+	Jogadores []string      `json:"jogadores,omitempty" bson:"jogadores,omitempty"` // todos, no modo dois jogadores
+	Pontos    int           `json:"pontos" bson:"pontos"`
+	Data      time.Time     `json:"data" bson:"data"`
+}
+
+// nome do jogador na posicao slot da partida
+func (r *Replay) PlayerName(slot int) string {
+	if slot < len(r.Jogadores) {
+		return r.Jogadores[slot]
+	}
+	if slot == 0 {
+		return r.Jogador
+	}
+	return ""
+}
+
+func (r *Replay) playerCount() int {
+	if len(r.Jogadores) > 1 {
+		return len(r.Jogadores)
+	}
+	return 1
+}
+
+// replays sem modo sao de partidas solo
+func (r *Replay) mode() string {
+	if r.Mode == "" {
+		return MODE_SOLO
+	}
+	return r.Mode
+}
+
+// toca um replay tick a tick
+type ReplayPlayer struct {
+	Sim    *Simulation
+	replay *Replay
+	next   int
+	Done   bool
+}
+
+func NewReplayPlayer(r *Replay) (*ReplayPlayer, error) {
+	if r.Version != REPLAY_VERSION {
+		return nil, fmt.Errorf("replay na versao %d, esperado %d", r.Version, REPLAY_VERSION)
+	}
+	return &ReplayPlayer{
+		Sim:    NewMatchSimulation(r.Width, r.Height, r.Seed, r.mode(), r.playerCount()),
+		replay: r,
+	}, nil
+}
+
+// avanca um tick com as entradas gravadas, false quando o replay acabou
+func (p *ReplayPlayer) Step() bool {
+	if p.Done {
+		return false
+	}
+
+	var inputs []Input
+	if p.next < len(p.replay.Frames) && p.replay.Frames[p.next].Tick == p.Sim.TickCount {
+		inputs = p.replay.Frames[p.next].Inputs
+		p.next++
+	}
+
+	alive := p.Sim.Step(inputs...)
+	if !alive || p.Sim.TickCount >= p.replay.Ticks {
+		p.Done = true
+	}
+	return !p.Done
+}
+
+// roda o replay ate o fim e devolve a simulacao no estado final
+func (r *Replay) Run() (*Simulation, error) {
+	p, err := NewReplayPlayer(r)
+	if err != nil {
+		return nil, err
+	}
+	for p.Step() {
+	}
+	return p.Sim, nil
+}
+
+func replayDir() string {
+	return filepath.Join(dataDir(), "replays")
+}
+
+func SaveReplay(r *Replay) (string, error) {
+	path := filepath.Join(replayDir(), "replay-"+r.Data.Format("20060102-150405.000")+".json")
+	return path, writeJSONFile(path, r)
+}
+
+func LoadReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// arquivos de replay salvos, do mais recente para o mais antigo
+func ListReplays() ([]string, error) {
+	entries, err := os.ReadDir(replayDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), "replay-") && strings.HasSuffix(e.Name(), ".json") {
+			paths = append(paths, filepath.Join(replayDir(), e.Name()))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths, nil
+}
@@ -0,0 +1,148 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// velocidades do avanco rapido, em ticks por quadro
+var replaySpeeds = []int{1, 2, 4, 8, 16}
+
+func (g *Game) showReplays() {
+	paths, err := ListReplays()
+	if err != nil {
+		log.Printf("Erro ao listar replays: %v", err)
+	}
+	if len(paths) > 15 {
+		paths = paths[:15]
+	}
+
+	replays := make([]*Replay, 0, len(paths))
+	for _, path := range paths {
+		r, err := LoadReplay(path)
+		if err != nil {
+			log.Printf("Erro ao ler replay %s: %v", path, err)
+			continue
+		}
+		replays = append(replays, r)
+	}
+
+	selected := 0
+	for {
+		g.r.Clear()
+		width, height := g.r.Size()
+
+		title := "ASSISTIR REPLAY"
+		g.drawText((width-len(title))/2, 2, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault, title)
+
+		if len(replays) == 0 {
+			noReplays := "Nenhum replay gravado ainda!"
+			g.drawText((width-len(noReplays))/2, height/2, termbox.ColorWhite, termbox.ColorDefault, noReplays)
+		}
+
+		for i, r := range replays {
+			line := fmt.Sprintf("%s  %-14s %6d pts  %s",
+				r.Data.Format("02/01 15:04"), r.Jogador, r.Pontos, time.Duration(r.Ticks)*TICK_RATE/time.Second*time.Second)
+			x := (width - len(line)) / 2
+
+			fgColor := termbox.ColorWhite
+			if i == selected {
+				fgColor = termbox.ColorYellow | termbox.AttrBold
+				g.drawText(x-2, 5+i, fgColor, termbox.ColorDefault, ">")
+			}
+			g.drawText(x, 5+i, fgColor, termbox.ColorDefault, line)
+		}
+
+		backMsg := "ENTER para assistir, ESC para voltar ao menu"
+		g.drawText((width-len(backMsg))/2, height-3, termbox.ColorGreen, termbox.ColorDefault, backMsg)
+		g.r.Flush()
+
+		ev := <-g.events
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		switch ev.Key {
+		case termbox.KeyArrowUp:
+			if len(replays) > 0 {
+				selected = (selected - 1 + len(replays)) % len(replays)
+			}
+		case termbox.KeyArrowDown:
+			if len(replays) > 0 {
+				selected = (selected + 1) % len(replays)
+			}
+		case termbox.KeyEnter:
+			if len(replays) > 0 {
+				g.watchReplay(replays[selected])
+			}
+		case termbox.KeyEsc:
+			return
+		}
+	}
+}
+
+// toca o replay pelo mesmo caminho de desenho da partida normal
+func (g *Game) watchReplay(r *Replay) {
+	player, err := NewReplayPlayer(r)
+	if err != nil {
+		log.Printf("Erro ao abrir replay: %v", err)
+		return
+	}
+	g.sim = player.Sim
+	g.arena = player.Sim.Arena
+	g.score = 0
+	defer func() { g.status = "" }()
+
+	speed := 0
+	paused := false
+
+	ticker := time.NewTicker(TICK_RATE)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev := <-g.events:
+			if ev.Type != termbox.EventKey {
+				continue
+			}
+			switch {
+			case ev.Key == termbox.KeyEsc:
+				return
+			case ev.Key == termbox.KeySpace:
+				paused = !paused
+			case ev.Key == termbox.KeyArrowRight || ev.Ch == '.':
+				// passo a passo so com o replay pausado
+				if paused {
+					player.Step()
+				}
+			case ev.Ch == '+' || ev.Ch == 'f':
+				if speed < len(replaySpeeds)-1 {
+					speed++
+				}
+			case ev.Ch == '-':
+				if speed > 0 {
+					speed--
+				}
+			}
+		case <-ticker.C:
+			if !paused {
+				for i := 0; i < replaySpeeds[speed] && player.Step(); i++ {
+				}
+			}
+		}
+
+		state := fmt.Sprintf("x%d", replaySpeeds[speed])
+		if paused {
+			state = "PAUSADO"
+		}
+		if player.Done {
+			state = "FIM"
+		}
+		g.status = fmt.Sprintf("REPLAY %s %d/%d • ESPACO pausa • → passo • +/- vel",
+			state, player.Sim.TickCount, r.Ticks)
+		g.score = g.arena.Points
+		g.drawGame()
+	}
+}
@@ -0,0 +1,239 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// quanto tempo um processo pode segurar a trava das migracoes; se ele
+// morrer no meio, outro assume depois disso
+const SCHEMA_LOCK_TTL = time.Minute
+
+// uma mudanca no banco. Toda migracao tem que poder rodar de novo sem
+// estragar nada: se o processo cair depois de aplicar e antes de registrar,
+// o proximo a conectar aplica outra vez
+type migration struct {
+	version int
+	name    string
+	up      func(ctx context.Context, db *mongo.Database) error
+}
+
+// migracoes em ordem de versao. Nova mudanca no banco entra no fim, com a
+// proxima versao; as que ja rodaram nunca mudam
+var migrations = []migration{
+	{1, "indices do ranking", createScoreIndexes},
+	{2, "validador de score", applyScoreValidator},
+	{3, "modo e verificado em scores antigos", backfillScoreFields},
+	{4, "indice de nome em players", createPlayerIndexes},
+	{5, "pontos negativos no validador", applyScoreValidator},
+	{6, "carga no validador", applyScoreValidator},
+}
+
+// registro de uma migracao aplicada, em schema_migrations
+type appliedMigration struct {
+	Versao   int       `bson:"_id"`
+	Nome     string    `bson:"nome"`
+	Aplicada time.Time `bson:"aplicada"`
+	Host     string    `bson:"host"`
+}
+
+// aplica as migracoes que faltam. Varios jogos e servidores conectam no
+// mesmo replica set ao mesmo tempo, entao so quem pega a trava migra; os
+// outros esperam e depois encontram tudo aplicado. Trava e registros sao
+// gravados com majority e lidos do primario, para uma troca de primario
+// nao fazer uma migracao rodar de novo
+func (m *MongoStore) MigrateSchema() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	col := m.db.Collection("schema_migrations", options.Collection().
+		SetWriteConcern(writeconcern.Majority()).
+		SetReadConcern(readconcern.Majority()).
+		SetReadPreference(readpref.Primary()))
+
+	release, err := lockSchema(ctx, col)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	cursor, err := col.Find(ctx, bson.M{"_id": bson.M{"$type": "number"}})
+	if err != nil {
+		return err
+	}
+	var done []appliedMigration
+	if err := cursor.All(ctx, &done); err != nil {
+		return err
+	}
+	applied := make(map[int]bool, len(done))
+	for _, a := range done {
+		applied[a.Versao] = true
+	}
+
+	for _, mig := range migrations {
+		if applied[mig.version] {
+			continue
+		}
+		log.Printf("Migracao %d: %s", mig.version, mig.name)
+		if err := mig.up(ctx, m.db); err != nil {
+			return fmt.Errorf("migracao %d (%s): %w", mig.version, mig.name, err)
+		}
+		_, err := col.InsertOne(ctx, appliedMigration{
+			Versao:   mig.version,
+			Nome:     mig.name,
+			Aplicada: time.Now(),
+			Host:     hostname(),
+		})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// trava com prazo em schema_migrations (_id "lock"). O upsert so acha o
+// documento se o prazo ja passou; com a trava de outro processo ele tenta
+// inserir um _id repetido e falha, entao tenta de novo em 1 segundo
+func lockSchema(ctx context.Context, col *mongo.Collection) (func(), error) {
+	token := newUUID()
+	waiting := false
+	for {
+		now := time.Now()
+		_, err := col.UpdateOne(ctx,
+			bson.M{"_id": "lock", "ate": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"ate": now.Add(SCHEMA_LOCK_TTL), "token": token, "host": hostname()}},
+			options.Update().SetUpsert(true),
+		)
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+		if !waiting {
+			log.Println("Outro processo esta migrando o banco, aguardando...")
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	release := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err := col.UpdateOne(ctx,
+			bson.M{"_id": "lock", "token": token},
+			bson.M{"$set": bson.M{"ate": time.Time{}}},
+		)
+		if err != nil {
+			log.Printf("Erro ao liberar a trava das migracoes: %v", err)
+		}
+	}
+	return release, nil
+}
+
+// indices do ranking. Criar um indice que ja existe nao faz nada
+func createScoreIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("snake_scores").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		// ranking geral e por periodo (o filtro de data anda no mesmo indice)
+		{Keys: bson.D{{Key: "pontos", Value: -1}, {Key: "data", Value: 1}}, Options: options.Index().SetName("ranking")},
+		// historico e busca de posicao do jogador
+		{Keys: bson.D{{Key: "nome", Value: 1}, {Key: "data", Value: -1}}, Options: options.Index().SetName("jogador_data")},
+		{Keys: bson.D{{Key: "jogador_id", Value: 1}, {Key: "pontos", Value: -1}}, Options: options.Index().SetName("perfil_pontos")},
+	})
+	return err
+}
+
+func createPlayerIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("players").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "nome", Value: 1}}, Options: options.Index().SetName("nome"),
+	})
+	return err
+}
+
+// $jsonSchema dos documentos de snake_scores. Campos novos no Score
+// precisam de uma migracao que troque o validador. Pontos podem ficar
+// negativos (a fruta de penalidade tira 20), o resto sao contagens
+func scoreSchema() bson.M {
+	count := bson.M{"bsonType": "number", "minimum": 0}
+	return bson.M{
+		"bsonType": "object",
+		"required": bson.A{"nome", "pontos", "data"},
+		"properties": bson.M{
+			"_id":         bson.M{"bsonType": bson.A{"string", "objectId"}},
+			"nome":        bson.M{"bsonType": "string", "minLength": 1, "maxLength": 40},
+			"pontos":      bson.M{"bsonType": "number"},
+			"data":        bson.M{"bsonType": "date"},
+			"jogador_id":  bson.M{"bsonType": "string"},
+			"nivel":       count,
+			"max_combo":   count,
+			"cheats":      bson.M{"bsonType": "array", "items": bson.M{"bsonType": "string"}},
+			"partida":     bson.M{"bsonType": "string"},
+			"modo":        bson.M{"bsonType": "string"},
+			"slot":        count,
+			"tamanho":     count,
+			"duracao":     count,
+			"frutas":      bson.M{"bsonType": "object", "additionalProperties": count},
+			"bosses":      count,
+			"lacaios":     count,
+			"causa_morte": bson.M{"bsonType": "string"},
+			"versao_jogo": bson.M{"bsonType": "string"},
+			"host":        bson.M{"bsonType": "string"},
+			"carga":       bson.M{"bsonType": "bool"},
+			"replay":      bson.M{"bsonType": "object"},
+			"verificado":  bson.M{"bsonType": "bool"},
+			"flags":       bson.M{"bsonType": "array", "items": bson.M{"bsonType": "string"}},
+		},
+	}
+}
+
+// cria snake_scores com o validador, ou troca o validador se ela ja
+// existe. Nivel moderate: documentos antigos fora do schema continuam
+// la, so insercoes e documentos validos passam pela validacao
+func applyScoreValidator(ctx context.Context, db *mongo.Database) error {
+	validator := bson.M{"$jsonSchema": scoreSchema()}
+	err := db.CreateCollection(ctx, "snake_scores", options.CreateCollection().
+		SetValidator(validator).
+		SetValidationLevel("moderate").
+		SetValidationAction("error"))
+	var ce mongo.CommandError
+	if !errors.As(err, &ce) || ce.Code != 48 { // 48: NamespaceExists
+		return err
+	}
+	return db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: "snake_scores"},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+		{Key: "validationAction", Value: "error"},
+	}).Err()
+}
+
+// scores de antes do multiplayer e da verificacao nao tem modo nem
+// verificado; o filtro $exists deixa a migracao rodar de novo sem efeito
+func backfillScoreFields(ctx context.Context, db *mongo.Database) error {
+	scores := db.Collection("snake_scores")
+	if _, err := scores.UpdateMany(ctx,
+		bson.M{"modo": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"modo": MODE_SOLO}},
+	); err != nil {
+		return err
+	}
+	_, err := scores.UpdateMany(ctx,
+		bson.M{"verificado": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"verificado": false}},
+	)
+	return err
+}
@@ -0,0 +1,494 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sem nenhuma mensagem (nem ping) nesse tempo o cliente cai
+const NET_TIMEOUT = 15 * time.Second
+
+// entradas guardadas por jogador; rajadas que chegam juntas por causa da
+// rede sao aplicadas uma por tick, o excesso e descartado
+const MAX_QUEUED_INPUTS = 4
+
+type ServerOptions struct {
+	Players    int           // jogadores por partida, ate MAX_PLAYERS
+	MinPlayers int           // depois de LobbyWait comeca com esse minimo
+	LobbyWait  time.Duration // espera pelo lobby cheio
+	Store      ScoreStore
+}
+
+// Server roda arenas autoritativas: os clientes so mandam direcoes e
+// recebem snapshots; pontos, colisoes e resultado sao calculados aqui
+type Server struct {
+	opts ServerOptions
+
+	mu         sync.Mutex
+	lobby      []*netClient
+	lobbySince time.Time
+	wake       chan struct{}
+
+	// espectadores esperando partida e os de cada partida em andamento
+	idle *spectatorHub
+	live []*spectatorHub
+}
+
+func NewServer(opts ServerOptions) *Server {
+	if opts.Players < 1 {
+		opts.Players = 2
+	}
+	if opts.Players > MAX_PLAYERS {
+		opts.Players = MAX_PLAYERS
+	}
+	if opts.MinPlayers < 1 || opts.MinPlayers > opts.Players {
+		opts.MinPlayers = opts.Players
+	}
+	if opts.LobbyWait <= 0 {
+		opts.LobbyWait = 10 * time.Second
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore()
+	}
+	return &Server{opts: opts, wake: make(chan struct{}, 1), idle: newSpectatorHub()}
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("Servidor ouvindo em %s (%d jogadores por partida)", ln.Addr(), s.opts.Players)
+	return s.Serve(ln)
+}
+
+func (s *Server) Serve(ln net.Listener) error {
+	go s.matchmaker()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// conexao de um cliente: fila de saida propria e a partida em que esta
+type netClient struct {
+	conn      net.Conn
+	name      string
+	out       chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu         sync.Mutex
+	inputs     []queuedInput
+	appliedSeq int
+	quit       bool
+	needKey    bool
+	inMatch    bool
+
+	watching *spectatorHub // protegido por Server.mu
+}
+
+type queuedInput struct {
+	in  Input
+	seq int
+}
+
+func newNetClient(conn net.Conn) *netClient {
+	c := &netClient{
+		conn: conn,
+		out:  make(chan []byte, 16),
+		done: make(chan struct{}),
+	}
+	go c.writeLoop()
+	return c
+}
+
+func (c *netClient) writeLoop() {
+	for {
+		select {
+		case data := <-c.out:
+			c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+			if _, err := c.conn.Write(data); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *netClient) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// estado pode ser descartado se o cliente estiver lento: ele recebe um
+// snapshot completo depois. Mensagens de controle esperam a fila andar
+func (c *netClient) send(msg NetMessage) {
+	data := encodeNetMessage(msg)
+	if msg.Type == MSG_STATE {
+		select {
+		case c.out <- data:
+		default:
+			c.mu.Lock()
+			c.needKey = true
+			c.mu.Unlock()
+		}
+		return
+	}
+	select {
+	case c.out <- data:
+	case <-c.done:
+	case <-time.After(5 * time.Second):
+		c.close()
+	}
+}
+
+func (c *netClient) queueInput(in Input, seq int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.inMatch || len(c.inputs) >= MAX_QUEUED_INPUTS {
+		return
+	}
+	if n := len(c.inputs); n > 0 && c.inputs[n-1].in == in {
+		c.inputs[n-1].seq = seq
+		return
+	}
+	c.inputs = append(c.inputs, queuedInput{in: in, seq: seq})
+}
+
+// proxima entrada para o tick; a saida do jogador vale uma vez so
+func (c *netClient) nextInput() (Input, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.quit {
+		c.quit = false
+		c.inputs = nil
+		return INPUT_QUIT, true
+	}
+	if len(c.inputs) == 0 {
+		return 0, false
+	}
+	q := c.inputs[0]
+	c.inputs = c.inputs[1:]
+	c.appliedSeq = q.seq
+	return q.in, true
+}
+
+// le o que o cliente manda ate a conexao cair
+func (s *Server) handle(conn net.Conn) {
+	c := newNetClient(conn)
+	defer func() {
+		c.close()
+		s.leave(c)
+	}()
+
+	err := readNetMessages(deadlineReader{conn}, func(msg NetMessage) bool {
+		switch msg.Type {
+		case MSG_JOIN:
+			c.mu.Lock()
+			playing := c.inMatch
+			c.mu.Unlock()
+			if playing {
+				return true
+			}
+			if c.name == "" {
+				c.name = cleanPlayerName(msg.Name)
+			}
+			s.join(c)
+		case MSG_WATCH:
+			c.mu.Lock()
+			playing := c.inMatch
+			c.mu.Unlock()
+			if !playing {
+				s.watch(c)
+			}
+		case MSG_INPUT:
+			if in, ok := netDirs[msg.Dir]; ok {
+				c.queueInput(in, msg.Seq)
+			}
+		case MSG_PING:
+			c.send(NetMessage{Type: MSG_PONG, Time: msg.Time})
+		default:
+			c.send(NetMessage{Type: MSG_ERROR, Error: "mensagem desconhecida: " + msg.Type})
+		}
+		return true
+	})
+	if err != nil {
+		log.Printf("Cliente %s desconectado: %v", conn.RemoteAddr(), err)
+	}
+}
+
+// renova o prazo de leitura a cada leitura
+type deadlineReader struct {
+	conn net.Conn
+}
+
+func (r deadlineReader) Read(p []byte) (int, error) {
+	r.conn.SetReadDeadline(time.Now().Add(NET_TIMEOUT))
+	return r.conn.Read(p)
+}
+
+// nomes vem do cliente: sem espacos nas pontas e com tamanho limitado
+func cleanPlayerName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "jogador"
+	}
+	if r := []rune(name); len(r) > 20 {
+		name = string(r[:20])
+	}
+	return name
+}
+
+func (s *Server) join(c *netClient) {
+	s.mu.Lock()
+	for _, other := range s.lobby {
+		if other == c {
+			s.mu.Unlock()
+			return
+		}
+	}
+	if len(s.lobby) == 0 {
+		s.lobbySince = time.Now()
+	}
+	s.lobby = append(s.lobby, c)
+	s.mu.Unlock()
+
+	s.announceLobby()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// cliente caiu: sai do lobby ou da partida (a cobra dele e derrubada)
+func (s *Server) leave(c *netClient) {
+	s.mu.Lock()
+	for i, other := range s.lobby {
+		if other == c {
+			s.lobby = append(s.lobby[:i], s.lobby[i+1:]...)
+			break
+		}
+	}
+	hub := c.watching
+	s.mu.Unlock()
+	if hub != nil {
+		hub.remove(c)
+	}
+
+	c.mu.Lock()
+	if c.inMatch {
+		c.quit = true
+	}
+	c.mu.Unlock()
+	s.announceLobby()
+}
+
+// espectador entra na partida mais recente, ou espera a proxima
+func (s *Server) watch(c *netClient) {
+	s.mu.Lock()
+	if c.watching != nil {
+		s.mu.Unlock()
+		return
+	}
+	hub := s.idle
+	if len(s.live) > 0 {
+		hub = s.live[len(s.live)-1]
+	}
+	c.watching = hub
+	s.mu.Unlock()
+	hub.add(c)
+}
+
+// move os espectadores de um hub para outro; chamado com s.mu e com o
+// destino ainda sem partida, entao nada e enviado aqui
+func (s *Server) moveSpectators(from, to *spectatorHub) {
+	for _, c := range from.snapshotClients() {
+		from.remove(c)
+		c.watching = to
+		to.add(c)
+	}
+}
+
+func (s *Server) announceLobby() {
+	s.mu.Lock()
+	names := make([]string, len(s.lobby))
+	for i, c := range s.lobby {
+		names[i] = c.name
+	}
+	clients := append([]*netClient(nil), s.lobby...)
+	s.mu.Unlock()
+
+	for _, c := range clients {
+		c.send(NetMessage{Type: MSG_LOBBY, Lobby: names, Needed: s.opts.Players})
+	}
+}
+
+// comeca uma partida quando o lobby enche, ou com o minimo depois da espera
+func (s *Server) matchmaker() {
+	check := time.NewTicker(500 * time.Millisecond)
+	defer check.Stop()
+	for {
+		select {
+		case <-s.wake:
+		case <-check.C:
+		}
+
+		s.mu.Lock()
+		ready := len(s.lobby) >= s.opts.Players ||
+			(len(s.lobby) >= s.opts.MinPlayers && time.Since(s.lobbySince) >= s.opts.LobbyWait)
+		var players []*netClient
+		if ready {
+			n := len(s.lobby)
+			if n > s.opts.Players {
+				n = s.opts.Players
+			}
+			players = append(players, s.lobby[:n]...)
+			s.lobby = append([]*netClient(nil), s.lobby[n:]...)
+			s.lobbySince = time.Now()
+		}
+		s.mu.Unlock()
+
+		if ready {
+			go s.runMatch(players)
+			s.announceLobby()
+		}
+	}
+}
+
+// uma partida inteira: aplica as entradas, avanca a arena no ritmo do
+// TICK_RATE e manda o estado para todos
+func (s *Server) runMatch(clients []*netClient) {
+	names := make([]string, len(clients))
+	for i, c := range clients {
+		names[i] = c.name
+	}
+	log.Printf("Partida comecou: %s", strings.Join(names, ", "))
+
+	sim := NewMatchSimulation(60, 25, time.Now().UnixNano(), MODE_ONLINE, len(clients))
+	for i, c := range clients {
+		c.mu.Lock()
+		c.inMatch = true
+		c.needKey = true
+		c.inputs = nil
+		c.appliedSeq = 0
+		c.mu.Unlock()
+		c.send(NetMessage{Type: MSG_START, Slot: i, Lobby: names})
+	}
+
+	// quem estava esperando passa a assistir esta partida
+	spectators := newSpectatorHub()
+	s.mu.Lock()
+	s.moveSpectators(s.idle, spectators)
+	s.live = append(s.live, spectators)
+	s.mu.Unlock()
+	spectators.start(names)
+
+	enc := newSnapshotEncoder()
+	ticker := time.NewTicker(TICK_RATE)
+	defer ticker.Stop()
+
+	for alive := true; alive; {
+		<-ticker.C
+
+		var inputs []Input
+		for i, c := range clients {
+			if in, ok := c.nextInput(); ok {
+				inputs = append(inputs, PlayerInput(i, in))
+			}
+		}
+		alive = sim.Step(inputs...)
+
+		keyframe := sim.TickCount%KEYFRAME_TICKS == 1
+		delta := enc.encode(sim.Arena, sim.TickCount, names, keyframe)
+		delta.Spectators = spectators.count()
+		var full *Snapshot
+		fullSnapshot := func() *Snapshot {
+			if full == nil {
+				full = newSnapshotEncoder().encode(sim.Arena, sim.TickCount, names, true)
+				full.Spectators = delta.Spectators
+			}
+			return full
+		}
+		for _, c := range clients {
+			c.mu.Lock()
+			snap, seq := delta, c.appliedSeq
+			if c.needKey && !keyframe {
+				snap = fullSnapshot()
+			}
+			c.needKey = false
+			c.mu.Unlock()
+			c.send(NetMessage{Type: MSG_STATE, Seq: seq, State: snap})
+		}
+		spectators.send(delta, fullSnapshot)
+	}
+
+	results := s.saveMatch(sim, names)
+	for _, c := range clients {
+		c.mu.Lock()
+		c.inMatch = false
+		c.inputs = nil
+		c.mu.Unlock()
+		c.send(NetMessage{Type: MSG_OVER, Results: results})
+	}
+
+	// espectadores veem o resultado e voltam a esperar a proxima partida
+	spectators.over(results)
+	s.mu.Lock()
+	for i, hub := range s.live {
+		if hub == spectators {
+			s.live = append(s.live[:i], s.live[i+1:]...)
+			break
+		}
+	}
+	s.moveSpectators(spectators, s.idle)
+	s.mu.Unlock()
+}
+
+// grava um score por jogador (com o replay da partida, para verificacao)
+// e devolve os resultados sem o replay para mandar aos clientes. Scores da
+// rede nao levam perfil: o id viria do cliente e qualquer um poderia usar
+// o de outro jogador
+func (s *Server) saveMatch(sim *Simulation, names []string) []Score {
+	now := time.Now()
+	replay := sim.Replay()
+	replay.Jogador = names[0]
+	replay.Jogadores = names
+	replay.Pontos = sim.Arena.Players[0].Points
+	replay.Data = now
+	if _, err := SaveReplay(replay); err != nil {
+		log.Printf("Erro ao salvar replay: %v", err)
+	}
+
+	match := newScoreID()
+	results := make([]Score, 0, len(names))
+	var summary []string
+	for i, p := range sim.Arena.Players {
+		score := newScore(sim.Arena, i, names[i], now)
+		score.Replay = replay
+		score.Partida = match
+		if err := s.opts.Store.Save(score); err != nil {
+			log.Printf("Erro ao salvar score de %s: %v", names[i], err)
+		}
+		score.Replay = nil
+		results = append(results, score)
+		summary = append(summary, fmt.Sprintf("%s %d", names[i], p.Points))
+	}
+	log.Printf("Partida %s terminou: %s", match, strings.Join(summary, ", "))
+	return results
+}
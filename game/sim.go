@@ -0,0 +1,138 @@
+package game
+
+import (
+	"math/rand"
+	"time"
+)
+
+// duracao de um tick da simulacao
+const TICK_RATE = 120 * time.Millisecond
+
+// instante inicial do relogio da simulacao, fixo para que duas execucoes
+// com a mesma seed produzam exatamente o mesmo estado
+var simEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// entradas do jogador aceitas pela simulacao
+type Input int
+
+const (
+	INPUT_UP Input = iota
+	INPUT_DOWN
+	INPUT_LEFT
+	INPUT_RIGHT
+	INPUT_CHEAT_GROW
+	INPUT_CHEAT_POINTS
+	INPUT_CHEAT_LEVEL
+	INPUT_CHEAT_BOSS
+	INPUT_CHEAT_KILL
+	INPUT_P2_UP
+	INPUT_P2_DOWN
+	INPUT_P2_LEFT
+	INPUT_P2_RIGHT
+	INPUT_QUIT // jogador saiu da partida (desconectou)
+)
+
+// entradas do jogador na posicao slot >= 2 valem INPUT_PLAYER_BASE*slot + entrada
+const INPUT_PLAYER_BASE = 100
+
+// entrada do jogador na posicao slot da partida
+func PlayerInput(slot int, in Input) Input {
+	switch {
+	case slot == 0:
+		return in
+	case slot == 1 && in >= INPUT_UP && in <= INPUT_RIGHT:
+		return INPUT_P2_UP + (in - INPUT_UP)
+	}
+	return Input(INPUT_PLAYER_BASE*slot) + in
+}
+
+// inverso de PlayerInput
+func splitPlayerInput(in Input) (int, Input) {
+	switch {
+	case in >= INPUT_PLAYER_BASE:
+		return int(in) / INPUT_PLAYER_BASE, in % INPUT_PLAYER_BASE
+	case in >= INPUT_P2_UP && in <= INPUT_P2_RIGHT:
+		return 1, INPUT_UP + (in - INPUT_P2_UP)
+	}
+	return 0, in
+}
+
+// fonte de tempo da arena, injetavel para rodar sem relogio real
+type Clock interface {
+	Now() time.Time
+}
+
+// relogio que so avanca quando mandado
+type ManualClock struct {
+	now time.Time
+}
+
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+func (c *ManualClock) Now() time.Time { return c.now }
+
+func (c *ManualClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// Simulation roda a arena sem terminal: estado + entrada -> proximo estado.
+// Cada Step avanca o relogio em TICK_RATE, entao tudo depende apenas da
+// seed e das entradas recebidas
+type Simulation struct {
+	Arena     *Arena
+	Clock     *ManualClock
+	Seed      int64
+	TickCount int
+	frames    []ReplayFrame
+}
+
+func NewSimulation(width, height int, seed int64) *Simulation {
+	return NewMatchSimulation(width, height, seed, MODE_SOLO, 1)
+}
+
+// simulacao com players cobras seguindo as regras do modo
+func NewMatchSimulation(width, height int, seed int64, mode string, players int) *Simulation {
+	clock := NewManualClock(simEpoch)
+	rng := rand.New(rand.NewSource(seed))
+	return &Simulation{
+		Arena: newArena(width, height, mode, players, clock, rng),
+		Clock: clock,
+		Seed:  seed,
+	}
+}
+
+// aplica as entradas e avanca um tick, retorna false quando a partida acaba
+func (s *Simulation) Step(inputs ...Input) bool {
+	if len(inputs) > 0 {
+		s.frames = append(s.frames, ReplayFrame{
+			Tick:   s.TickCount,
+			Inputs: append([]Input(nil), inputs...),
+		})
+	}
+	for _, in := range inputs {
+		s.Arena.ApplyInput(in)
+	}
+	s.Clock.Advance(TICK_RATE)
+	s.TickCount++
+	return s.Arena.Tick()
+}
+
+// tempo de jogo decorrido desde o inicio da simulacao
+func (s *Simulation) Elapsed() time.Duration {
+	return time.Duration(s.TickCount) * TICK_RATE
+}
+
+// replay com tudo que foi jogado ate agora
+func (s *Simulation) Replay() *Replay {
+	return &Replay{
+		Version: REPLAY_VERSION,
+		Mode:    s.Arena.Mode,
+		Seed:    s.Seed,
+		Width:   s.Arena.Width,
+		Height:  s.Arena.Height,
+		Ticks:   s.TickCount,
+		Frames:  append([]ReplayFrame(nil), s.frames...),
+	}
+}
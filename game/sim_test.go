@@ -0,0 +1,73 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testTicks = 2000
+
+// joga com o autopilot e devolve a simulacao com as entradas gravadas
+func playTestGame(t *testing.T, seed int64) *Simulation {
+	t.Helper()
+	sim := PlayAutopilot(60, 25, seed, testTicks)
+	if sim.Arena.Points == 0 {
+		t.Fatal("autopilot nao fez pontos, o teste nao compararia nada")
+	}
+	return sim
+}
+
+// mesma seed e mesmas entradas por tick, mesma arena
+func TestSimulationDeterministic(t *testing.T) {
+	first := playTestGame(t, 42)
+
+	second := NewSimulation(60, 25, 42)
+	frames := first.Replay().Frames
+	for second.TickCount < first.TickCount {
+		var inputs []Input
+		if len(frames) > 0 && frames[0].Tick == second.TickCount {
+			inputs = frames[0].Inputs
+			frames = frames[1:]
+		}
+		if !second.Step(inputs...) {
+			break
+		}
+	}
+
+	a, b := first.Arena, second.Arena
+	if second.TickCount != first.TickCount {
+		t.Fatalf("ticks: %d, esperado %d", second.TickCount, first.TickCount)
+	}
+	if a.Level != b.Level {
+		t.Errorf("nivel: %d, esperado %d", b.Level, a.Level)
+	}
+	if !reflect.DeepEqual(a.Players, b.Players) {
+		t.Errorf("jogadores divergiram: pontos %d, esperado %d", b.Points, a.Points)
+	}
+	if !reflect.DeepEqual(a.Foods, b.Foods) {
+		t.Errorf("comidas divergiram: %v, esperado %v", b.Foods, a.Foods)
+	}
+	if !reflect.DeepEqual(a.Obstacles, b.Obstacles) {
+		t.Error("obstaculos divergiram")
+	}
+}
+
+// o replay gravado refaz a partida com o mesmo resultado
+func TestReplayReproducesScore(t *testing.T) {
+	sim := playTestGame(t, 7)
+
+	replayed, err := sim.Replay().Run()
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want, got := sim.Arena, replayed.Arena
+	if got.Points != want.Points {
+		t.Errorf("pontos: %d, esperado %d", got.Points, want.Points)
+	}
+	if got.Level != want.Level {
+		t.Errorf("nivel: %d, esperado %d", got.Level, want.Level)
+	}
+	if got.ComboSystem.MaxCombo != want.ComboSystem.MaxCombo {
+		t.Errorf("combo: %d, esperado %d", got.ComboSystem.MaxCombo, want.ComboSystem.MaxCombo)
+	}
+}
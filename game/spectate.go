@@ -0,0 +1,236 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// espectadores de uma partida: recebem os mesmos snapshots dos jogadores,
+// mas nao mandam entradas
+type spectatorHub struct {
+	mu      sync.Mutex
+	clients []*netClient
+	names   []string
+
+	// so o jogo local usa: o servidor monta os snapshots em runMatch
+	enc      *snapshotEncoder
+	lastTick int
+}
+
+func newSpectatorHub() *spectatorHub {
+	return &spectatorHub{enc: newSnapshotEncoder()}
+}
+
+// novo espectador: recebe o inicio da partida e um snapshot completo no
+// proximo tick
+func (h *spectatorHub) add(c *netClient) {
+	h.mu.Lock()
+	h.clients = append(h.clients, c)
+	names := h.names
+	h.mu.Unlock()
+
+	c.mu.Lock()
+	c.needKey = true
+	c.mu.Unlock()
+	if names != nil {
+		c.send(NetMessage{Type: MSG_START, Slot: -1, Lobby: names})
+	}
+}
+
+func (h *spectatorHub) remove(c *netClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, other := range h.clients {
+		if other == c {
+			h.clients = append(h.clients[:i], h.clients[i+1:]...)
+			return
+		}
+	}
+}
+
+func (h *spectatorHub) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+func (h *spectatorHub) snapshotClients() []*netClient {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]*netClient(nil), h.clients...)
+}
+
+// partida nova: todos recebem o inicio e depois um snapshot completo
+func (h *spectatorHub) start(names []string) {
+	h.mu.Lock()
+	h.names = names
+	h.mu.Unlock()
+	for _, c := range h.snapshotClients() {
+		c.mu.Lock()
+		c.needKey = true
+		c.mu.Unlock()
+		c.send(NetMessage{Type: MSG_START, Slot: -1, Lobby: names})
+	}
+}
+
+// manda o delta do tick; quem entrou agora (ou ficou para tras) recebe o
+// snapshot completo
+func (h *spectatorHub) send(delta *Snapshot, full func() *Snapshot) {
+	for _, c := range h.snapshotClients() {
+		c.mu.Lock()
+		snap := delta
+		if c.needKey && !delta.Keyframe {
+			snap = full()
+		}
+		c.needKey = false
+		c.mu.Unlock()
+		c.send(NetMessage{Type: MSG_STATE, State: snap})
+	}
+}
+
+func (h *spectatorHub) over(results []Score) {
+	h.mu.Lock()
+	h.names = nil
+	h.mu.Unlock()
+	for _, c := range h.snapshotClients() {
+		c.send(NetMessage{Type: MSG_OVER, Results: results})
+	}
+}
+
+// snapshot do jogo local a cada tick; uma partida nova (tick voltou)
+// avisa os espectadores e recomeca os deltas
+func (h *spectatorHub) broadcast(a *Arena, tick int, names []string) {
+	h.mu.Lock()
+	restarted := h.names == nil || tick < h.lastTick
+	h.lastTick = tick
+	h.mu.Unlock()
+	if restarted {
+		h.enc = newSnapshotEncoder()
+		h.start(names)
+	}
+
+	keyframe := restarted || tick%KEYFRAME_TICKS == 1
+	delta := h.enc.encode(a, tick, names, keyframe)
+	delta.Spectators = h.count()
+	var full *Snapshot
+	h.send(delta, func() *Snapshot {
+		if full == nil {
+			full = newSnapshotEncoder().encode(a, tick, names, true)
+			full.Spectators = delta.Spectators
+		}
+		return full
+	})
+}
+
+// recebe espectadores para o jogo local; o protocolo e o mesmo do
+// servidor, entao "snake watch" serve para os dois
+func listenSpectators(addr string, h *spectatorHub) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSpectator(conn, h)
+		}
+	}()
+	return ln, nil
+}
+
+func serveSpectator(conn net.Conn, h *spectatorHub) {
+	c := newNetClient(conn)
+	defer func() {
+		c.close()
+		h.remove(c)
+	}()
+
+	watching := false
+	err := readNetMessages(deadlineReader{conn}, func(msg NetMessage) bool {
+		switch msg.Type {
+		case MSG_WATCH:
+			if !watching {
+				watching = true
+				h.add(c)
+			}
+		case MSG_PING:
+			c.send(NetMessage{Type: MSG_PONG, Time: msg.Time})
+		default:
+			c.send(NetMessage{Type: MSG_ERROR, Error: "so espectadores aqui: " + msg.Type})
+		}
+		return true
+	})
+	if err != nil {
+		log.Printf("Espectador %s saiu: %v", conn.RemoteAddr(), err)
+	}
+}
+
+// assiste uma partida (do servidor ou de um jogo local com -spectate)
+// sem jogar: as teclas so servem para sair
+func (g *Game) watchOnline(addr string) error {
+	sc, err := dialServer(addr, NetMessage{Type: MSG_WATCH, Name: g.userID})
+	if err != nil {
+		return err
+	}
+	defer sc.Close()
+
+	g.watching = true
+	defer func() { g.watching = false }()
+	g.arena = newMirrorArena()
+	g.status = ""
+	var names []string
+	var rtt time.Duration
+	g.drawNetMessage("Assistindo "+addr, "Aguardando uma partida...")
+
+	ping := time.NewTicker(time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case ev := <-g.events:
+			if ev.Type == termbox.EventKey && (ev.Key == termbox.KeyEsc || ev.Key == termbox.KeyCtrlC) {
+				return nil
+			}
+
+		case msg, ok := <-sc.msgs:
+			if !ok {
+				return errors.New("conexao com a partida caiu")
+			}
+			switch msg.Type {
+			case MSG_START:
+				names = msg.Lobby
+				g.arena = newMirrorArena()
+			case MSG_STATE:
+				if msg.State == nil {
+					continue
+				}
+				msg.State.applyTo(g.arena, -1)
+				g.score = g.arena.Points
+				g.spectators = msg.State.Spectators
+				g.status = fmt.Sprintf("Espectador • %s • ping %dms", strings.Join(names, " x "), rtt.Milliseconds())
+				g.drawGame()
+			case MSG_OVER:
+				g.drawOnlineResults(msg.Results, -1)
+			case MSG_PONG:
+				rtt = time.Since(time.Unix(0, msg.Time))
+			case MSG_ERROR:
+				g.status = "Servidor: " + msg.Error
+			}
+
+		case <-ping.C:
+			if err := sc.ping(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package game
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// chave do servidor fica no diretorio de dados: os clientes nao reclamam
+// de chave trocada a cada reinicio
+const SSH_HOST_KEY_FILE = "ssh_host_ed25519_key"
+
+type SSHOptions struct {
+	Store   ScoreStore // compartilhado por todas as sessoes
+	DevMode bool
+	KeyPath string // vazio usa SSH_HOST_KEY_FILE no dataDir
+}
+
+// SSHServer da a cada sessao SSH o seu proprio Game, desenhado com o
+// ANSIRenderer direto no canal. O nome do jogador e o usuario do SSH
+type SSHServer struct {
+	opts   SSHOptions
+	config *ssh.ServerConfig
+}
+
+func NewSSHServer(opts SSHOptions) (*SSHServer, error) {
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore()
+	}
+	if opts.KeyPath == "" {
+		opts.KeyPath = filepath.Join(dataDir(), SSH_HOST_KEY_FILE)
+	}
+	signer, err := loadHostKey(opts.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// sem senha: o usuario do SSH e so a identidade no ranking
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+	return &SSHServer{opts: opts, config: config}, nil
+}
+
+// le a chave do servidor ou gera uma nova na primeira vez
+func loadHostKey(path string) (ssh.Signer, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		return ssh.ParsePrivateKey(data)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ssh.MarshalPrivateKey(key, "snake-game")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		return nil, err
+	}
+	log.Printf("Chave SSH nova gerada em %s", path)
+	return ssh.NewSignerFromKey(key)
+}
+
+func (s *SSHServer) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("SSH ouvindo em %s", ln.Addr())
+	return s.Serve(ln)
+}
+
+func (s *SSHServer) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *SSHServer) handleConn(conn net.Conn) {
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		log.Printf("Handshake SSH com %s falhou: %v", conn.RemoteAddr(), err)
+		return
+	}
+	defer sconn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	name := cleanPlayerName(sconn.User())
+	log.Printf("SSH: %s conectou de %s", name, sconn.RemoteAddr())
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			newChan.Reject(ssh.UnknownChannelType, "so sessoes")
+			continue
+		}
+		ch, chReqs, err := newChan.Accept()
+		if err != nil {
+			continue
+		}
+		go s.handleSession(name, ch, chReqs)
+	}
+	log.Printf("SSH: %s saiu", name)
+}
+
+// payloads de pedidos de sessao (RFC 4254, secao 6.2 e 6.7)
+type ptyRequest struct {
+	Term          string
+	Width, Height uint32
+	PxW, PxH      uint32
+	Modes         string
+}
+
+type windowChange struct {
+	Width, Height uint32
+	PxW, PxH      uint32
+}
+
+// uma sessao: espera o shell, roda o jogo e fecha o canal quando ele acaba
+func (s *SSHServer) handleSession(name string, ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+
+	width, height := 80, 24
+	var renderer *ANSIRenderer
+	done := make(chan struct{})
+
+	for {
+		select {
+		case req, ok := <-reqs:
+			if !ok {
+				return
+			}
+			switch req.Type {
+			case "pty-req":
+				var pty ptyRequest
+				if err := ssh.Unmarshal(req.Payload, &pty); err == nil && pty.Width > 0 && pty.Height > 0 {
+					width, height = int(pty.Width), int(pty.Height)
+				}
+				req.Reply(true, nil)
+			case "window-change":
+				var wc windowChange
+				if err := ssh.Unmarshal(req.Payload, &wc); err == nil && wc.Width > 0 && wc.Height > 0 {
+					width, height = int(wc.Width), int(wc.Height)
+					if renderer != nil {
+						renderer.Resize(width, height)
+					}
+				}
+			case "shell":
+				if renderer != nil {
+					req.Reply(false, nil)
+					continue
+				}
+				req.Reply(true, nil)
+				renderer = NewANSIRenderer(ch, ch, width, height)
+				// com o Store do servidor NewGame nao tem o que falhar
+				g, _ := NewGame(Options{Renderer: renderer, Store: s.opts.Store, DevMode: s.opts.DevMode, Name: name})
+				go func() {
+					g.Start()
+					close(done)
+				}()
+			default:
+				// exec, subsystem, env...: so o jogo interativo
+				req.Reply(false, nil)
+			}
+
+		case <-done:
+			status := make([]byte, 4)
+			binary.BigEndian.PutUint32(status, 0)
+			ch.SendRequest("exit-status", false, status)
+			return
+		}
+	}
+}
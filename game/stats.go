@@ -0,0 +1,99 @@
+package game
+
+import (
+	"errors"
+	"sort"
+)
+
+// quantas partidas recentes entram no grafico de tendencia
+const STATS_TREND = 20
+
+// historico de um jogador. Partidas com cheats ou sem verificacao ficam
+// de fora, como no ranking
+type PlayerStats struct {
+	Nome        string  `bson:"-" json:"nome"`
+	Partidas    int     `bson:"partidas" json:"partidas"`
+	Melhor      int     `bson:"melhor" json:"melhor"`
+	Media       float64 `bson:"media" json:"media"`
+	MelhorNivel int     `bson:"melhor_nivel" json:"melhor_nivel"`
+	MelhorCombo int     `bson:"melhor_combo" json:"melhor_combo"`
+	TempoTotal  float64 `bson:"tempo_total" json:"tempo_total"` // segundos
+
+	Recentes  []int `bson:"-" json:"recentes"`  // pontos das ultimas partidas, da mais antiga para a mais nova
+	Posicao   int   `bson:"-" json:"posicao"`   // pelo melhor score de cada jogador, 0 sem partidas
+	Jogadores int   `bson:"-" json:"jogadores"` // quantos jogadores tem no ranking
+}
+
+// StatsStore e opcional: o MongoStore calcula com pipelines de agregacao,
+// o MemoryStore (e o FileStore) percorre os scores em memoria. Com id o
+// historico e o do perfil, senao o do nome
+type StatsStore interface {
+	PlayerStats(name, id string) (PlayerStats, error)
+}
+
+var ErrStatsUnsupported = errors.New("store sem estatisticas")
+
+// PlayerStats do store, se ele tiver
+func playerStats(store ScoreStore, name, id string) (PlayerStats, error) {
+	if s, ok := store.(StatsStore); ok {
+		return s.PlayerStats(name, id)
+	}
+	return PlayerStats{Nome: name}, ErrStatsUnsupported
+}
+
+// jogador dono do score no ranking por jogador: o perfil, ou o nome em
+// scores sem perfil (o $ifNull do pipeline faz o mesmo)
+func statsKey(s Score) string {
+	if s.JogadorID != "" {
+		return s.JogadorID
+	}
+	return s.Nome
+}
+
+func computePlayerStats(scores []Score, name, id string) PlayerStats {
+	st := PlayerStats{Nome: name}
+	var history []Score
+	total := 0
+
+	for _, s := range scores {
+		if !rankable(s) || !isPlayerScore(s, name, id) {
+			continue
+		}
+		history = append(history, s)
+		total += s.Pontos
+		st.Partidas++
+		st.Melhor = max(st.Melhor, s.Pontos)
+		st.MelhorNivel = max(st.MelhorNivel, s.Nivel)
+		st.MelhorCombo = max(st.MelhorCombo, s.MaxCombo)
+		st.TempoTotal += s.Duracao
+	}
+	if st.Partidas == 0 {
+		return st
+	}
+	st.Media = float64(total) / float64(st.Partidas)
+
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Data.Before(history[j].Data) })
+	if len(history) > STATS_TREND {
+		history = history[len(history)-STATS_TREND:]
+	}
+	for _, s := range history {
+		st.Recentes = append(st.Recentes, s.Pontos)
+	}
+
+	// a posicao e a do ranking por jogador, na mesma ordem do PlayerRank
+	best := filterScores(scores, ScoreQuery{BestPerPlayer: true})
+	st.Jogadores = len(best)
+	for i, s := range best {
+		if isPlayerScore(s, name, id) {
+			st.Posicao = i + 1
+			break
+		}
+	}
+	return st
+}
+
+func (m *MemoryStore) PlayerStats(name, id string) (PlayerStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return computePlayerStats(m.scores, name, id), nil
+}
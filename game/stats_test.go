@@ -0,0 +1,50 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// empate de pontos: quem fez antes fica na frente, nas estatisticas e no
+// PlayerRank
+func TestPlayerStatsRankMatchesPlayerRank(t *testing.T) {
+	day := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	for _, s := range []Score{
+		{ID: "1", Nome: "ana", JogadorID: "a", Pontos: 100, Data: day, Verificado: true},
+		{ID: "2", Nome: "bia", Pontos: 100, Data: day.Add(time.Hour), Verificado: true},
+		{ID: "3", Nome: "bia", Pontos: 40, Data: day, Verificado: true},
+		{ID: "4", Nome: "caio", Pontos: 300, Data: day, Verificado: true},
+		{ID: "5", Nome: "duda", Pontos: 900, Data: day}, // sem verificacao, fora
+	} {
+		store.Save(s)
+	}
+
+	for _, p := range []struct {
+		name, id string
+		want     int
+	}{
+		{"caio", "", 1},
+		{"ana", "a", 2},
+		{"bia", "", 3},
+	} {
+		st, _ := store.PlayerStats(p.name, p.id)
+		rank, _ := store.PlayerRank(ScoreQuery{BestPerPlayer: true}, p.name, p.id)
+		if st.Posicao != p.want || rank != p.want {
+			t.Errorf("%s: estatisticas %d, PlayerRank %d, esperado %d", p.name, st.Posicao, rank, p.want)
+		}
+		if st.Jogadores != 3 {
+			t.Errorf("%s: %d jogadores, esperado 3", p.name, st.Jogadores)
+		}
+	}
+}
+
+// o maior combo aparece como no HUD, contando o multiplicador x1
+func TestDrawStatsCombo(t *testing.T) {
+	r := NewRecordingRenderer(80, 40)
+	g := newTestGame(r)
+	g.drawStats(PlayerStats{Nome: "ana", Partidas: 1, Melhor: 50, MelhorCombo: 3, Posicao: 1, Jogadores: 1}, nil)
+	if !r.Contains("x4") {
+		t.Errorf("tela de estatisticas:\n%s", r.Screen())
+	}
+}
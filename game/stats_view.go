@@ -0,0 +1,90 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// historico do jogador atual
+func (g *Game) showStats() {
+	stats, err := playerStats(g.store, g.userID, g.profileID)
+	if err != nil && !errors.Is(err, ErrStatsUnsupported) {
+		log.Printf("Erro ao buscar estatisticas: %v", err)
+	}
+
+	for {
+		g.drawStats(stats, err)
+
+		ev := <-g.events
+		if ev.Type == termbox.EventKey && ev.Key == termbox.KeyEsc {
+			return
+		}
+	}
+}
+
+func (g *Game) drawStats(st PlayerStats, err error) {
+	g.r.Clear()
+	width, height := g.r.Size()
+
+	title := "ESTATISTICAS - " + st.Nome
+	g.drawText((width-len([]rune(title)))/2, 2, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault, title)
+
+	switch {
+	case err != nil:
+		msg := "Estatisticas indisponiveis no momento"
+		g.drawText((width-len(msg))/2, height/2, termbox.ColorRed, termbox.ColorDefault, msg)
+	case st.Partidas == 0:
+		msg := "Nenhuma partida registrada ainda!"
+		g.drawText((width-len(msg))/2, height/2, termbox.ColorWhite, termbox.ColorDefault, msg)
+	default:
+		rows := [][2]string{
+			{"Posicao no ranking", fmt.Sprintf("%d de %d jogadores", st.Posicao, st.Jogadores)},
+			{"Partidas", fmt.Sprintf("%d", st.Partidas)},
+			{"Melhor pontuacao", fmt.Sprintf("%d", st.Melhor)},
+			{"Media", fmt.Sprintf("%.0f", st.Media)},
+			{"Melhor nivel", fmt.Sprintf("%d", st.MelhorNivel)},
+			{"Maior combo", fmt.Sprintf("x%d", st.MelhorCombo+1)}, // como no HUD
+			{"Tempo de jogo", (time.Duration(st.TempoTotal) * time.Second).String()},
+		}
+		x := (width - 44) / 2
+		for i, row := range rows {
+			g.drawText(x, 5+i, termbox.ColorCyan, termbox.ColorDefault, row[0])
+			g.drawText(x+22, 5+i, termbox.ColorWhite|termbox.AttrBold, termbox.ColorDefault, row[1])
+		}
+
+		y := 6 + len(rows)
+		g.drawText(x, y, termbox.ColorCyan, termbox.ColorDefault, fmt.Sprintf("Ultimas %d partidas", len(st.Recentes)))
+		g.drawText(x+22, y, termbox.ColorGreen|termbox.AttrBold, termbox.ColorDefault, sparkline(st.Recentes))
+	}
+
+	backMsg := "Pressione ESC para voltar ao menu"
+	g.drawText((width-len(backMsg))/2, height-3, termbox.ColorGreen, termbox.ColorDefault, backMsg)
+
+	g.r.Flush()
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// um bloco por valor, da altura relativa ao menor e ao maior
+func sparkline(values []int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	out := make([]rune, len(values))
+	for i, v := range values {
+		level := len(sparkBlocks) - 1
+		if hi > lo {
+			level = (v - lo) * (len(sparkBlocks) - 1) / (hi - lo)
+		}
+		out[i] = sparkBlocks[level]
+	}
+	return string(out)
+}
@@ -0,0 +1,374 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Score struct {
+	ID     string    `bson:"_id,omitempty" json:"id,omitempty"`
+	Nome   string    `bson:"nome" json:"nome"`
+	Pontos int       `bson:"pontos" json:"pontos"`
+	Data   time.Time `bson:"data" json:"data"`
+
+	// perfil de quem jogou; o nome pode mudar, o id nao
+	JogadorID string `bson:"jogador_id,omitempty" json:"jogador_id,omitempty"`
+
+	Nivel    int      `bson:"nivel" json:"nivel"`
+	MaxCombo int      `bson:"max_combo" json:"max_combo"`
+	Cheats   []string `bson:"cheats,omitempty" json:"cheats,omitempty"`
+
+	// partidas de dois jogadores: um score por jogador, ligados pela partida
+	Partida string `bson:"partida,omitempty" json:"partida,omitempty"`
+	Modo    string `bson:"modo,omitempty" json:"modo,omitempty"`
+	Slot    int    `bson:"slot,omitempty" json:"slot,omitempty"` // 0 = jogador 1
+
+	// estatisticas da partida; com replay o VerifyScore recalcula todas
+	Tamanho    int            `bson:"tamanho,omitempty" json:"tamanho,omitempty"`         // tamanho final da cobra
+	Duracao    float64        `bson:"duracao,omitempty" json:"duracao,omitempty"`         // segundos de jogo
+	Frutas     map[string]int `bson:"frutas,omitempty" json:"frutas,omitempty"`           // comidas por tipo
+	Bosses     int            `bson:"bosses,omitempty" json:"bosses,omitempty"`           // estrangeiros derrotados
+	Lacaios    int            `bson:"lacaios,omitempty" json:"lacaios,omitempty"`         // lacaios derrotados
+	CausaMorte string         `bson:"causa_morte,omitempty" json:"causa_morte,omitempty"` // DEATH_*
+	VersaoJogo string         `bson:"versao_jogo,omitempty" json:"versao_jogo,omitempty"`
+	Host       string         `bson:"host,omitempty" json:"host,omitempty"` // maquina que gravou
+
+	// enviado pelo snake loadtest: score de bot, fica fora do ranking
+	Carga bool `bson:"carga,omitempty" json:"carga,omitempty"`
+
+	// preenchidos pelo VerifyScore ao re-simular o replay
+	Replay     *Replay  `bson:"replay,omitempty" json:"replay,omitempty"`
+	Verificado bool     `bson:"verificado" json:"verificado"`
+	Flags      []string `bson:"flags,omitempty" json:"flags,omitempty"`
+}
+
+// versao do jogo gravada em cada score
+const GAME_VERSION = "2.0.0"
+
+// nomes dos tipos de fruta em Score.Frutas
+var foodTypeNames = [...]string{FOOD_NORMAL: "normal", FOOD_BONUS: "bonus", FOOD_PENALTY: "penalidade"}
+
+// score do jogador slot no fim da partida, com as estatisticas dele
+func newScore(a *Arena, slot int, name string, now time.Time) Score {
+	p := a.Players[slot]
+	s := Score{
+		ID:         newScoreID(),
+		Nome:       name,
+		Pontos:     p.Points,
+		Data:       now,
+		Nivel:      a.Level,
+		MaxCombo:   p.ComboSystem.MaxCombo,
+		Cheats:     a.CheatsUsed,
+		Modo:       a.Mode,
+		Slot:       slot,
+		VersaoJogo: GAME_VERSION,
+		Host:       hostname(),
+	}
+	s.setStats(a, p)
+	return s
+}
+
+// estatisticas que saem da arena (e sao recalculadas na verificacao)
+func (s *Score) setStats(a *Arena, p *Player) {
+	s.Tamanho = len(p.Snake.Body)
+	s.Duracao = (time.Duration(p.Ticks) * TICK_RATE).Seconds()
+	s.Frutas = make(map[string]int, len(foodTypeNames))
+	for t, n := range p.FoodsEaten {
+		s.Frutas[foodTypeNames[t]] = n
+	}
+	s.Bosses = p.BossesKilled
+	s.Lacaios = p.MinionsKilled
+	s.CausaMorte = p.DeathCause
+	if p.Alive {
+		s.CausaMorte = DEATH_ALIVE
+	}
+}
+
+// filtros de uma consulta ao ranking
+type ScoreQuery struct {
+	Limit          int       // 0 usa 10
+	IncludeCheated bool      // ranking dev: inclui partidas com cheats e nao verificadas
+	Player         string    // so os scores desse jogador
+	Since          time.Time // so os scores a partir dessa data (rankings por periodo)
+	Recent         bool      // historico: mais recentes primeiro, em vez de por pontos
+	BestPerPlayer  bool      // so o melhor score de cada jogador
+	Offset         int       // pula as primeiras posicoes (paginacao)
+}
+
+// periodos dos rankings
+const (
+	PERIOD_DAY   = "day"
+	PERIOD_WEEK  = "week"
+	PERIOD_MONTH = "month"
+	PERIOD_ALL   = "all"
+)
+
+// inicio do periodo que contem now: dia, semana (a partir de segunda) ou
+// mes. PERIOD_ALL devolve a data zero, que nao filtra nada
+func periodStart(period string, now time.Time) (time.Time, error) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case PERIOD_DAY:
+		return day, nil
+	case PERIOD_WEEK:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7), nil
+	case PERIOD_MONTH:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), nil
+	case PERIOD_ALL:
+		return time.Time{}, nil
+	}
+	return time.Time{}, fmt.Errorf("periodo desconhecido: %s", period)
+}
+
+func (q ScoreQuery) limit() int {
+	if q.Limit <= 0 {
+		return 10
+	}
+	return q.Limit
+}
+
+// ScoreStore guarda as pontuacoes e monta o ranking. O jogo recebe um
+// pronto em Options.Store, ou escolhe um com OpenScoreStore
+type ScoreStore interface {
+	Save(s Score) error
+	Top(q ScoreQuery) ([]Score, error)
+}
+
+// ScoreWatcher e opcional: stores que sabem avisar de scores novos (o
+// MongoStore, com change streams) deixam o ranking atualizar sozinho.
+// O canal fecha quando ctx acaba ou o stream cai
+type ScoreWatcher interface {
+	WatchScores(ctx context.Context) (<-chan Score, error)
+}
+
+var ErrWatchUnsupported = errors.New("store sem atualizacao ao vivo")
+
+// RankStore e opcional: acha a posicao do jogador (perfil id, ou o nome)
+// no ranking da consulta, contando de 1, sem trazer as paginas anteriores.
+// 0 quando ele nao tem score na consulta
+type RankStore interface {
+	PlayerRank(q ScoreQuery, name, id string) (int, error)
+}
+
+var ErrRankUnsupported = errors.New("store sem busca de posicao")
+
+// PlayerRank do store, se ele tiver
+func playerRank(store ScoreStore, q ScoreQuery, name, id string) (int, error) {
+	if r, ok := store.(RankStore); ok {
+		return r.PlayerRank(q, name, id)
+	}
+	return 0, ErrRankUnsupported
+}
+
+// o score e do jogador: pelo perfil quando tem id, senao pelo nome
+func isPlayerScore(s Score, name, id string) bool {
+	if id != "" {
+		return s.JogadorID == id
+	}
+	return s.Nome == name
+}
+
+// WatchScores do store, se ele tiver
+func watchScores(ctx context.Context, store ScoreStore) (<-chan Score, error) {
+	if w, ok := store.(ScoreWatcher); ok {
+		return w.WatchScores(ctx)
+	}
+	return nil, ErrWatchUnsupported
+}
+
+// escolhe o store pela config: MongoDB quando LoadMongoConfig tem uma URI,
+// senao um arquivo JSON local que sobrevive entre as partidas.
+// Todo score passa pelo VerifyingStore antes de ser gravado. O erro e so o
+// de uma config do MongoDB invalida: todo valor fora do padrao foi pedido
+// por alguem (arquivo, ambiente ou flag), entao cair no arquivo local
+// esconderia o engano
+func OpenScoreStore() (ScoreStore, error) {
+	store, err := openBaseStore()
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifyingStore(store), nil
+}
+
+func openBaseStore() (ScoreStore, error) {
+	cfg, err := LoadMongoConfig()
+	if err != nil {
+		return nil, fmt.Errorf("config do MongoDB invalida: %w", err)
+	}
+	if cfg.URI != "" {
+		store, err := NewMongoStore(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("config do MongoDB invalida: %w", err)
+		}
+		if err := store.WaitReady(); err != nil {
+			log.Printf("%v — scores ficam na fila local até o replica set voltar", err)
+		} else if err := store.MigrateSchema(); err != nil {
+			log.Printf("Erro ao preparar o banco: %v", err)
+		}
+		return NewQueuedStore(store, filepath.Join(dataDir(), "score_queue.jsonl")), nil
+	}
+	log.Println("Modo local detectado — scores serão salvos em arquivo local (sem MongoDB)")
+
+	path := filepath.Join(dataDir(), "scores.json")
+	store, err := NewFileStore(path)
+	if err != nil {
+		log.Printf("Erro ao abrir %s: %v — scores serão salvos apenas na sessão", path, err)
+		return NewMemoryStore(), nil
+	}
+	return store, nil
+}
+
+// ordena por pontos (empate: o mais antigo primeiro), ou por data no
+// historico, e aplica os filtros e a paginacao
+func rankScores(scores []Score, q ScoreQuery) []Score {
+	ranked := filterScores(scores, q)
+	if q.Offset >= len(ranked) {
+		return nil
+	}
+	ranked = ranked[q.Offset:]
+	if len(ranked) > q.limit() {
+		ranked = ranked[:q.limit()]
+	}
+	return ranked
+}
+
+// so entra no ranking (e nas estatisticas) score re-simulado e sem cheats
+func rankable(s Score) bool {
+	return s.Verificado && len(s.Cheats) == 0 && !s.Carga
+}
+
+// o ranking inteiro da consulta, sem paginacao
+func filterScores(scores []Score, q ScoreQuery) []Score {
+	ranked := make([]Score, 0, len(scores))
+	for _, s := range scores {
+		if !rankable(s) && !q.IncludeCheated {
+			continue
+		}
+		if q.Player != "" && s.Nome != q.Player {
+			continue
+		}
+		if s.Data.Before(q.Since) {
+			continue
+		}
+		ranked = append(ranked, s)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if q.Recent {
+			return ranked[i].Data.After(ranked[j].Data)
+		}
+		if ranked[i].Pontos != ranked[j].Pontos {
+			return ranked[i].Pontos > ranked[j].Pontos
+		}
+		return ranked[i].Data.Before(ranked[j].Data)
+	})
+	if q.BestPerPlayer && !q.Recent {
+		// ja esta ordenado: o primeiro de cada jogador e o melhor dele
+		seen := make(map[string]bool)
+		best := ranked[:0]
+		for _, s := range ranked {
+			if !seen[statsKey(s)] {
+				seen[statsKey(s)] = true
+				best = append(best, s)
+			}
+		}
+		ranked = best
+	}
+	return ranked
+}
+
+// ScoreStore em memoria, usado em testes e como ultimo recurso
+type MemoryStore struct {
+	mu     sync.Mutex
+	scores []Score
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (m *MemoryStore) Save(s Score) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.has(s.ID) {
+		return nil
+	}
+	m.scores = append(m.scores, s)
+	return nil
+}
+
+// mesmo id = mesmo score, salvar de novo nao duplica
+func (m *MemoryStore) has(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, s := range m.scores {
+		if s.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryStore) Top(q ScoreQuery) ([]Score, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return rankScores(m.scores, q), nil
+}
+
+func (m *MemoryStore) PlayerRank(q ScoreQuery, name, id string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, s := range filterScores(m.scores, q) {
+		if isPlayerScore(s, name, id) {
+			return i + 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// ScoreStore em arquivo JSON para jogar offline. Cada Save reescreve o
+// arquivo inteiro via arquivo temporario + rename, entao um crash no meio
+// da escrita nunca deixa o ranking corrompido
+type FileStore struct {
+	MemoryStore
+	path string
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+	f := &FileStore{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &f.scores); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (f *FileStore) Save(s Score) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.has(s.ID) {
+		return nil
+	}
+
+	scores := append(f.scores, s)
+	if err := writeJSONFile(f.path, scores); err != nil {
+		return err
+	}
+	f.scores = scores
+	log.Printf("Score local: %s — %d pontos", s.Nome, s.Pontos)
+	return nil
+}
@@ -0,0 +1,99 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 30, 0, 0, time.UTC)
+	}
+	midnight := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		period string
+		now    time.Time
+		want   time.Time
+	}{
+		{PERIOD_DAY, at(3, 4, 15), midnight(3, 4)},
+		{PERIOD_WEEK, at(3, 4, 15), midnight(3, 2)},  // quarta volta para segunda
+		{PERIOD_WEEK, at(3, 2, 0), midnight(3, 2)},   // segunda e o proprio dia
+		{PERIOD_WEEK, at(3, 8, 23), midnight(3, 2)},  // domingo volta seis dias
+		{PERIOD_WEEK, at(3, 1, 10), midnight(2, 23)}, // domingo no mes seguinte
+		{PERIOD_MONTH, at(3, 31, 23), midnight(3, 1)},
+		{PERIOD_MONTH, at(3, 1, 0), midnight(3, 1)},
+		{PERIOD_ALL, at(3, 4, 15), time.Time{}},
+	}
+	for _, tt := range tests {
+		got, err := periodStart(tt.period, tt.now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("%s em %s: %s (%v), esperado %s", tt.period, tt.now.Format("Mon 02/01 15h"), got, err, tt.want)
+		}
+	}
+	if _, err := periodStart("ano", at(3, 4, 15)); err == nil {
+		t.Error("periodo desconhecido aceito")
+	}
+}
+
+func scoreIDs(scores []Score) []string {
+	ids := make([]string, len(scores))
+	for i, s := range scores {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+func TestMemoryStoreTop(t *testing.T) {
+	now := time.Date(2026, 3, 4, 15, 0, 0, 0, time.UTC) // quarta
+	store := NewMemoryStore()
+	for _, s := range []Score{
+		{ID: "ana-1", Nome: "ana", JogadorID: "a", Pontos: 500, Data: now.AddDate(0, 0, -10)},
+		{ID: "ana-2", Nome: "Ana Paula", JogadorID: "a", Pontos: 300, Data: now}, // mesmo perfil, outro nome
+		{ID: "bia-1", Nome: "bia", Pontos: 400, Data: now.Add(-time.Hour)},
+		{ID: "bia-2", Nome: "bia", Pontos: 400, Data: now},                     // empate: o mais antigo na frente
+		{ID: "outra-ana", Nome: "ana", JogadorID: "b", Pontos: 350, Data: now}, // mesmo nome, outro perfil
+		{ID: "caio", Nome: "caio", Pontos: 200, Data: now.AddDate(0, 0, -3)},   // domingo
+		{ID: "cheat", Nome: "duda", Pontos: 999, Data: now, Cheats: []string{"pontos"}},
+	} {
+		if s.Cheats == nil {
+			s.Verificado = true
+		}
+		store.Save(s)
+	}
+	week, _ := periodStart(PERIOD_WEEK, now)
+
+	tests := []struct {
+		name string
+		q    ScoreQuery
+		want []string
+	}{
+		{"todas", ScoreQuery{}, []string{"ana-1", "bia-1", "bia-2", "outra-ana", "ana-2", "caio"}},
+		{"pagina 2", ScoreQuery{Limit: 2, Offset: 2}, []string{"bia-2", "outra-ana"}},
+		{"pagina alem do fim", ScoreQuery{Limit: 2, Offset: 6}, []string{}},
+		{"melhor por jogador", ScoreQuery{BestPerPlayer: true}, []string{"ana-1", "bia-1", "outra-ana", "caio"}},
+		{"melhor por jogador, pagina 2", ScoreQuery{BestPerPlayer: true, Limit: 2, Offset: 2}, []string{"outra-ana", "caio"}},
+		{"semana", ScoreQuery{Since: week}, []string{"bia-1", "bia-2", "outra-ana", "ana-2"}},
+		{"semana por jogador", ScoreQuery{Since: week, BestPerPlayer: true}, []string{"bia-1", "outra-ana", "ana-2"}},
+		{"com cheats", ScoreQuery{IncludeCheated: true, Limit: 1}, []string{"cheat"}},
+		{"historico", ScoreQuery{Player: "bia", Recent: true}, []string{"bia-2", "bia-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Top(tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := scoreIDs(got)
+			if len(ids) != len(tt.want) {
+				t.Fatalf("%v, esperado %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("%v, esperado %v", ids, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -51,6 +51,14 @@ type Obstacle struct {
 	IsTemporary  bool
 	SpawnTime    time.Time
 	Lifetime     time.Duration
+
+	// so OBSTACLE_MOVING: rota de patrulha e posicao atual nela
+	Path      []Coord
+	PathIndex int
+	PathStep  int  // +1 ou -1 (vai e volta)
+	Loop      bool // rota fechada, sempre no mesmo sentido
+	Speed     time.Duration
+	LastMove  time.Time
 }
 
 // estrangeiro inimigo
@@ -62,6 +70,15 @@ type Boss struct {
 	Points   int
 	IsAlive  bool
 	Health   int // verificar la no boos.go
+
+	MaxHealth         int
+	Phase             int // 1, 2 ou 3, sobe conforme perde vida
+	InvulnerableUntil time.Time
+	IsMinion          bool // lacaio criado pelo estrangeiro na fase 3
+	Kind              int  // BOSS_THIEF, BOSS_HUNTER...
+	moves             int
+	baseSpeed         time.Duration
+	lastSummon        time.Time
 }
 
 // controla o sistema de combos
@@ -3,7 +3,9 @@ package game
 import (
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"sync"
 )
 
@@ -22,3 +24,63 @@ func generateUserID() string {
 	})
 	return userID
 }
+
+var hostName string
+var hostNameOnce sync.Once
+
+// nome da maquina, gravado nos scores para saber de que no vieram
+func hostname() string {
+	hostNameOnce.Do(func() {
+		name, err := os.Hostname()
+		if err != nil {
+			name = "unknown"
+		}
+		hostName = name
+	})
+	return hostName
+}
+
+// diretorio dos arquivos locais do jogo (scores, replays, perfis).
+// SNAKE_DATA_DIR tem prioridade sobre o diretorio de config do usuario
+func dataDir() string {
+	if dir := os.Getenv("SNAKE_DATA_DIR"); dir != "" {
+		return dir
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "snake-go")
+	}
+	return ".snake-go"
+}
+
+// grava v como JSON de forma atomica: arquivo temporario + rename
+func writeJSONFile(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// limite de ticks re-simulados por score: uma hora de jogo no TICK_RATE,
+// bem acima de uma partida real e ainda poucos segundos de CPU
+const MAX_REPLAY_TICKS = 30000
+
+// marcas gravadas em Score.Flags
+const (
+	FLAG_SEM_REPLAY = "sem_replay"
+	FLAG_CHEATS     = "cheats"
+)
+
+var ErrScoreRejected = errors.New("score rejeitado")
+
+// re-simula o replay do score e confere pontos, nivel e combo. Divergencia
+// rejeita o score; score sem replay ou com cheats passa, mas marcado
+func VerifyScore(s Score) (Score, error) {
+	// nada que o cliente diga sobre verificacao vale
+	s.Verificado = false
+	s.Flags = nil
+
+	r := s.Replay
+	if r == nil {
+		s.Flags = append(s.Flags, FLAG_SEM_REPLAY)
+		return s, nil
+	}
+	if r.Ticks < 0 || r.Ticks > MAX_REPLAY_TICKS || r.Width < 10 || r.Height < 10 || r.Width > 200 || r.Height > 200 {
+		return s, fmt.Errorf("%w: replay invalido", ErrScoreRejected)
+	}
+
+	sim, err := r.Run()
+	if err != nil {
+		return s, fmt.Errorf("%w: %v", ErrScoreRejected, err)
+	}
+	a := sim.Arena
+	if s.Slot < 0 || s.Slot >= len(a.Players) {
+		return s, fmt.Errorf("%w: jogador %d nao existe na partida", ErrScoreRejected, s.Slot+1)
+	}
+	p := a.Players[s.Slot]
+
+	var problems []string
+	if name := r.PlayerName(s.Slot); name != s.Nome {
+		problems = append(problems, fmt.Sprintf("jogador: enviado %q, replay de %q", s.Nome, name))
+	}
+	if p.Points != s.Pontos {
+		problems = append(problems, fmt.Sprintf("pontos: enviado %d, recalculado %d", s.Pontos, p.Points))
+	}
+	if a.Level != s.Nivel {
+		problems = append(problems, fmt.Sprintf("nivel: enviado %d, recalculado %d", s.Nivel, a.Level))
+	}
+	if p.ComboSystem.MaxCombo != s.MaxCombo {
+		problems = append(problems, fmt.Sprintf("combo: enviado %d, recalculado %d", s.MaxCombo, p.ComboSystem.MaxCombo))
+	}
+	if len(problems) > 0 {
+		return s, fmt.Errorf("%w: %s", ErrScoreRejected, strings.Join(problems, "; "))
+	}
+
+	// os cheats gravados sao os que a re-simulacao usou, nao os declarados
+	s.Cheats = a.CheatsUsed
+	s.Modo = r.mode()
+	s.setStats(a, p)
+	if len(s.Cheats) > 0 {
+		s.Flags = append(s.Flags, FLAG_CHEATS)
+	}
+	s.Verificado = true
+	return s, nil
+}
+
+// VerifyingStore confere cada score com VerifyScore antes de repassar ao
+// store de baixo, entao nada entra em snake_scores sem ser re-simulado
+type VerifyingStore struct {
+	ScoreStore
+}
+
+func NewVerifyingStore(inner ScoreStore) *VerifyingStore {
+	return &VerifyingStore{ScoreStore: inner}
+}
+
+func (v *VerifyingStore) Save(s Score) error {
+	checked, err := VerifyScore(s)
+	if err != nil {
+		return err
+	}
+	return v.ScoreStore.Save(checked)
+}
+
+func (v *VerifyingStore) WatchScores(ctx context.Context) (<-chan Score, error) {
+	return watchScores(ctx, v.ScoreStore)
+}
+
+func (v *VerifyingStore) SaveProfile(ctx context.Context, p Profile) error {
+	return saveProfile(ctx, v.ScoreStore, p)
+}
+
+func (v *VerifyingStore) PlayerRank(q ScoreQuery, name, id string) (int, error) {
+	return playerRank(v.ScoreStore, q, name, id)
+}
+
+func (v *VerifyingStore) PlayerStats(name, id string) (PlayerStats, error) {
+	return playerStats(v.ScoreStore, name, id)
+}
+
+func (v *VerifyingStore) Close() error {
+	if c, ok := v.ScoreStore.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
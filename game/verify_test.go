@@ -0,0 +1,139 @@
+package game
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// score de uma simulacao ja jogada, com o replay gravado
+func simScore(sim *Simulation, slot int, names ...string) Score {
+	replay := sim.Replay()
+	replay.Jogador = names[0]
+	if len(names) > 1 {
+		replay.Jogadores = names
+	}
+	s := newScore(sim.Arena, slot, names[slot], simEpoch)
+	s.Replay = replay
+	return s
+}
+
+func TestVerifyScore(t *testing.T) {
+	solo := PlayAutopilot(60, 25, 5, 500)
+
+	versus := NewMatchSimulation(60, 25, 5, MODE_VERSUS, 2)
+	for i := 0; i < 20 && versus.Step(); i++ {
+	}
+
+	cheats := NewSimulation(60, 25, 5)
+	cheats.Step(INPUT_CHEAT_POINTS)
+	for i := 0; i < 10 && cheats.Step(); i++ {
+	}
+
+	tests := []struct {
+		name     string
+		score    func() Score
+		rejected bool
+		flag     string // marca esperada em Flags
+		rankable bool
+	}{
+		{"replay confere", func() Score { return simScore(solo, 0, "ana") }, false, "", true},
+		{"pontos alterados", func() Score {
+			s := simScore(solo, 0, "ana")
+			s.Pontos += 10
+			return s
+		}, true, "", false},
+		{"nivel alterado", func() Score {
+			s := simScore(solo, 0, "ana")
+			s.Nivel++
+			return s
+		}, true, "", false},
+		{"combo alterado", func() Score {
+			s := simScore(solo, 0, "ana")
+			s.MaxCombo++
+			return s
+		}, true, "", false},
+		{"nome de outro jogador", func() Score {
+			s := simScore(solo, 0, "ana")
+			s.Nome = "bia"
+			return s
+		}, true, "", false},
+		{"posicao fora da partida", func() Score {
+			s := simScore(solo, 0, "ana")
+			s.Slot = 1
+			return s
+		}, true, "", false},
+		{"jogador 2 com o nome do 1", func() Score {
+			s := simScore(versus, 1, "ana", "bia")
+			s.Nome = "ana"
+			return s
+		}, true, "", false},
+		{"jogador 2 confere", func() Score { return simScore(versus, 1, "ana", "bia") }, false, "", true},
+		{"cheats", func() Score { return simScore(cheats, 0, "ana") }, false, FLAG_CHEATS, false},
+		{"sem replay", func() Score {
+			s := simScore(solo, 0, "ana")
+			s.Replay = nil
+			return s
+		}, false, FLAG_SEM_REPLAY, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := tt.score()
+			in.Verificado = true // o que o cliente diz nao vale
+			got, err := VerifyScore(in)
+			if tt.rejected {
+				if !errors.Is(err, ErrScoreRejected) {
+					t.Fatalf("erro: %v, esperado ErrScoreRejected", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("rejeitado: %v", err)
+			}
+			if tt.flag != "" && !slices.Contains(got.Flags, tt.flag) {
+				t.Errorf("flags: %v, esperado %s", got.Flags, tt.flag)
+			}
+			if rankable(got) != tt.rankable {
+				t.Errorf("rankable: %v, esperado %v (verificado %v, cheats %v)", rankable(got), tt.rankable, got.Verificado, got.Cheats)
+			}
+		})
+	}
+}
+
+// score sem replay fica fora do ranking do store
+func TestUnverifiedScoreNotRanked(t *testing.T) {
+	store := NewMemoryStore()
+	s := simScore(PlayAutopilot(60, 25, 5, 500), 0, "ana")
+	s.Replay = nil
+	if err := NewVerifyingStore(store).Save(s); err != nil {
+		t.Fatal(err)
+	}
+	if top, _ := store.Top(ScoreQuery{}); len(top) != 0 {
+		t.Errorf("ranking: %v, esperado vazio", top)
+	}
+	if top, _ := store.Top(ScoreQuery{IncludeCheated: true}); len(top) != 1 {
+		t.Errorf("ranking dev: %d scores, esperado 1", len(top))
+	}
+}
+
+// scores do teste de carga sao verificados, mas ficam fora do ranking
+func TestLoadTestNotRanked(t *testing.T) {
+	store := NewMemoryStore()
+	res := RunLoadTest(NewVerifyingStore(store), 3, 2, 1, 500)
+	if res.Sent != 3 || res.Failed != 0 {
+		t.Fatalf("loadtest: %v", res)
+	}
+	all, _ := store.Top(ScoreQuery{IncludeCheated: true})
+	if len(all) != 3 {
+		t.Fatalf("store: %d scores, esperado 3", len(all))
+	}
+	for _, s := range all {
+		if !s.Verificado || !s.Carga {
+			t.Errorf("score %s: verificado %v, carga %v", s.Nome, s.Verificado, s.Carga)
+		}
+	}
+	if top, _ := store.Top(ScoreQuery{}); len(top) != 0 {
+		t.Errorf("ranking: %v, esperado vazio", top)
+	}
+}
@@ -5,6 +5,8 @@ go 1.25.1
 require (
 	github.com/nsf/termbox-go v1.1.1
 	go.mongodb.org/mongo-driver v1.17.6
+	golang.org/x/crypto v0.26.0
+	golang.org/x/term v0.23.0
 )
 
 require (
@@ -17,7 +19,7 @@ require (
 	github.com/xdg-go/scram v1.1.2 // indirect
 	github.com/xdg-go/stringprep v1.0.4 // indirect
 	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78 // indirect
-	golang.org/x/crypto v0.26.0 // indirect
 	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/sys v0.23.0 // indirect
 	golang.org/x/text v0.17.0 // indirect
 )
@@ -1,7 +1,232 @@
 package main
 
-import "snake-game-distributed/game"
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"snake-game-distributed/game"
+)
 
 func main() {
-	game.NewGame().Start()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		case "loadtest":
+			runLoadTest(os.Args[2:])
+			return
+		case "server":
+			runServer(os.Args[2:])
+			return
+		case "connect":
+			runConnect(os.Args[2:])
+			return
+		case "watch":
+			runWatch(os.Args[2:])
+			return
+		case "ssh":
+			runSSH(os.Args[2:])
+			return
+		case "api":
+			runAPI(os.Args[2:])
+			return
+		}
+	}
+
+	render := flag.String("render", "termbox", "backend de desenho: termbox ou ansi")
+	dev := flag.Bool("dev", os.Getenv("SNAKE_DEV") != "", "modo desenvolvedor: libera cheats (ou SNAKE_DEV=1)")
+	demo := flag.Bool("demo", false, "abre direto na demonstracao com o autopilot")
+	spectate := flag.String("spectate", "", "endereco para espectadores assistirem (ex: :7778)")
+	game.MongoFlags(flag.CommandLine)
+	flag.Parse()
+
+	opts := game.Options{DevMode: *dev, Demo: *demo, Spectate: *spectate, Renderer: newRenderer(*render)}
+
+	// fecha o store no fim para a fila local tentar um ultimo envio
+	store, err := game.OpenScoreStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts.Store = store
+	if c, ok := opts.Store.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	g, err := game.NewGame(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	g.Start()
+}
+
+func newRenderer(name string) game.Renderer {
+	switch name {
+	case "termbox":
+		return game.NewTermboxRenderer()
+	case "ansi":
+		return game.NewANSIRenderer(os.Stdin, os.Stdout, 0, 0)
+	}
+	log.Fatalf("backend de desenho desconhecido: %s", name)
+	return nil
+}
+
+// snake bench: partidas do autopilot sem terminal, so as medias
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	games := fs.Int("n", 100, "numero de partidas")
+	seed := fs.Int64("seed", 1, "seed da primeira partida (as seguintes somam 1)")
+	ticks := fs.Int("ticks", game.AUTOPILOT_MAX_TICKS, "limite de ticks por partida")
+	fs.Parse(args)
+
+	fmt.Println(game.RunBenchmark(*games, *seed, *ticks))
+}
+
+// snake loadtest: envia scores do autopilot para o banco configurado
+func runLoadTest(args []string) {
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	games := fs.Int("n", 50, "numero de scores enviados")
+	workers := fs.Int("workers", 4, "envios em paralelo")
+	seed := fs.Int64("seed", 1, "seed da primeira partida (as seguintes somam 1)")
+	ticks := fs.Int("ticks", game.AUTOPILOT_MAX_TICKS, "limite de ticks por partida")
+	game.MongoFlags(fs)
+	fs.Parse(args)
+
+	store, err := game.OpenScoreStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if c, ok := store.(io.Closer); ok {
+		defer c.Close()
+	}
+	fmt.Println(game.RunLoadTest(store, *games, *workers, *seed, *ticks))
+}
+
+// snake server: arena autoritativa para varios jogadores via TCP
+func runServer(args []string) {
+	fs := flag.NewFlagSet("server", flag.ExitOnError)
+	addr := fs.String("addr", ":7777", "endereco de escuta")
+	players := fs.Int("players", 2, "jogadores por partida (ate 8)")
+	minPlayers := fs.Int("min", 2, "minimo de jogadores depois da espera do lobby")
+	wait := fs.Duration("wait", 10*time.Second, "espera pelo lobby cheio")
+	game.MongoFlags(fs)
+	fs.Parse(args)
+
+	store, err := game.OpenScoreStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if c, ok := store.(io.Closer); ok {
+		defer c.Close()
+	}
+	srv := game.NewServer(game.ServerOptions{Players: *players, MinPlayers: *minPlayers, LobbyWait: *wait, Store: store})
+	if err := srv.ListenAndServe(*addr); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// snake connect: joga (ou poe um bot para jogar) num servidor
+func runConnect(args []string) {
+	fs := flag.NewFlagSet("connect", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:7777", "endereco do servidor")
+	name := fs.String("name", "", "nome do jogador (padrao: perfil local)")
+	render := fs.String("render", "termbox", "backend de desenho: termbox ou ansi")
+	bot := fs.Bool("bot", false, "autopilot sem terminal no lugar do jogador")
+	matches := fs.Int("matches", 0, "com -bot: partidas antes de sair (0 = sem fim)")
+	fs.Parse(args)
+
+	if *bot {
+		botName := *name
+		if botName == "" {
+			botName = fmt.Sprintf("bot-%d", os.Getpid())
+		}
+		if err := game.RunBotClient(*addr, botName, *matches); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	g, err := game.NewGame(game.Options{Renderer: newRenderer(*render), Store: game.NewMemoryStore(), Name: *name})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := g.StartOnline(*addr); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// snake watch: assiste uma partida do servidor ou de um jogo com -spectate
+func runWatch(args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:7777", "endereco do servidor ou do jogo")
+	render := fs.String("render", "termbox", "backend de desenho: termbox ou ansi")
+	fs.Parse(args)
+
+	g, err := game.NewGame(game.Options{Renderer: newRenderer(*render), Store: game.NewMemoryStore()})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := g.StartWatching(*addr); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// snake ssh: cada sessao SSH joga a sua partida, com o usuario do SSH
+// como nome no ranking
+func runSSH(args []string) {
+	fs := flag.NewFlagSet("ssh", flag.ExitOnError)
+	addr := fs.String("addr", ":2222", "endereco de escuta")
+	key := fs.String("key", "", "chave do servidor (padrao: gerada no diretorio de dados)")
+	dev := fs.Bool("dev", false, "libera os cheats nas sessoes")
+	game.MongoFlags(fs)
+	fs.Parse(args)
+
+	store, err := game.OpenScoreStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if c, ok := store.(io.Closer); ok {
+		defer c.Close()
+	}
+	srv, err := game.NewSSHServer(game.SSHOptions{Store: store, DevMode: *dev, KeyPath: *key})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := srv.ListenAndServe(*addr); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// snake api: ranking em HTTP/JSON para paginas e outras ferramentas
+func runAPI(args []string) {
+	fs := flag.NewFlagSet("api", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "endereco de escuta")
+	game.MongoFlags(fs)
+	fs.Parse(args)
+
+	store, err := game.OpenScoreStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if c, ok := store.(io.Closer); ok {
+		defer c.Close()
+	}
+	// timeouts para conexoes lentas nao prenderem o servidor; a escrita
+	// cobre a re-simulacao de um envio
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           game.NewAPIHandler(store),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+	}
+	log.Printf("API ouvindo em %s", *addr)
+	if err := srv.ListenAndServe(); err != nil {
+		log.Fatal(err)
+	}
 }